@@ -53,18 +53,23 @@ func (rc *RootCommand) setFlags() {
 func (rc *RootCommand) registerSubCommands() {
 	rc.baseCmd.AddCommand(
 		sort.GetCommand(),
+		sort.GetLintCommand(),
+		sort.GetRenameCommand(),
+		sort.GetSplitCommand(),
+		sort.GetMergeCommand(),
 		version.GetCommand(),
 	)
 }
 
-// Exit code 2 is used for --check failures; exit code 1 for all other errors.
+// Exit code 2 is used for --check failures and lint violations; exit code 1
+// for all other errors.
 //
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func (rc *RootCommand) Execute() {
 	err := rc.baseCmd.Execute()
 	if err != nil {
-		if errors.Is(err, sort.ErrCheckFailed) {
+		if errors.Is(err, sort.ErrCheckFailed) || errors.Is(err, sort.ErrLintFailed) {
 			os.Exit(2)
 		}
 		os.Exit(1)
@@ -118,6 +123,11 @@ func initConfig(cmd *cobra.Command, args []string) {
 
 	bindFlags(cmd, v)
 
+	// Settings without a flag equivalent (e.g. file-rules) are decoded directly.
+	if err := sort.BindConfig(v); err != nil {
+		log.WithError(err).Fatalln("Error reading config file")
+	}
+
 	v.SetDefault("author", fmt.Sprintf("%s <%s>", info.AppRepoOwner, info.AppRepoOwnerEmail))
 	v.SetDefault("license", info.AppLicense)
 }
@@ -134,6 +144,11 @@ func bindFlags(cmd *cobra.Command, v *viper.Viper) {
 				for _, s := range v.GetStringSlice(configName) {
 					_ = cmd.Flags().Set(f.Name, s)
 				}
+			} else if f.Value.Type() == "stringToString" {
+				// Maps are set one key=value pair at a time; pflag merges them.
+				for k, s := range v.GetStringMapString(configName) {
+					_ = cmd.Flags().Set(f.Name, k+"="+s)
+				}
 			} else {
 				val := v.Get(configName)
 				_ = cmd.Flags().Set(f.Name, fmt.Sprintf("%v", val))
@@ -7,6 +7,8 @@ import (
 	"testing"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 func TestNewRootCommand(t *testing.T) {
@@ -20,7 +22,7 @@ func TestNewRootCommand(t *testing.T) {
 	for _, c := range cmds {
 		names[c.Name()] = true
 	}
-	for _, want := range []string{"sort", "version"} {
+	for _, want := range []string{"sort", "lint", "rename", "split", "merge", "version"} {
 		if !names[want] {
 			t.Errorf("expected sub-command %q to be registered", want)
 		}
@@ -122,3 +124,21 @@ func TestBindFlagsStringArrayFromConfig(t *testing.T) {
 		t.Logf("exclude flag value: %q (config binding may require full execution)", val)
 	}
 }
+
+func TestBindFlagsStringToStringFromConfig(t *testing.T) {
+	var comparators map[string]string
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().StringToStringVar(&comparators, "block-comparators", map[string]string{}, "")
+
+	v := viper.New()
+	v.Set("block-comparators", map[string]interface{}{
+		"output":   "preserve-original",
+		"resource": "label:1",
+	})
+
+	bindFlags(cmd, v)
+
+	if comparators["output"] != "preserve-original" || comparators["resource"] != "label:1" {
+		t.Errorf("block-comparators not bound from config, got %v", comparators)
+	}
+}
@@ -341,3 +341,189 @@ func TestCheckMode_MultipleFiles_AllClean(t *testing.T) {
 		t.Fatalf("expected nil for all-clean directory, got: %v", err)
 	}
 }
+
+// ─── Check mode: --group-by-type with configured file groups ───────────────
+
+func TestCheckMode_GroupByType_FileGroups(t *testing.T) {
+	t.Parallel()
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/filegroups", 0755)
+
+	_ = afero.WriteFile(memFS, "/filegroups/providers.tf", []byte(
+		"provider \"aws\" {\n  region = \"us-east-1\"\n}\n",
+	), 0644)
+	_ = afero.WriteFile(memFS, "/filegroups/terraform.tf", []byte(
+		"terraform {\n  required_version = \">= 1.5\"\n}\n",
+	), 0644)
+
+	t.Run("configured names are clean", func(t *testing.T) {
+		s := NewSorter(&Params{
+			Check:       true,
+			GroupByType: true,
+			FileGroups:  map[string]string{"provider": "providers.tf", "terraform": "terraform.tf"},
+		}, memFS)
+		if err := s.run("/filegroups"); err != nil {
+			t.Fatalf("expected nil for files matching file-groups, got: %v", err)
+		}
+	})
+
+	t.Run("default names report new files", func(t *testing.T) {
+		s := NewSorter(&Params{Check: true, GroupByType: true}, memFS)
+		err := s.run("/filegroups")
+		if !errors.Is(err, ErrCheckFailed) {
+			t.Fatalf("expected ErrCheckFailed, got: %v", err)
+		}
+		if !strings.Contains(err.Error(), "versions.tf") {
+			t.Errorf("expected error to mention versions.tf, got: %v", err)
+		}
+	})
+
+	t.Run("invalid file name is rejected", func(t *testing.T) {
+		s := NewSorter(&Params{
+			Check:       true,
+			GroupByType: true,
+			FileGroups:  map[string]string{"provider": "../providers.tf"},
+		}, memFS)
+		err := s.run("/filegroups")
+		if err == nil || !strings.Contains(err.Error(), "file-groups[provider]") {
+			t.Fatalf("expected file-groups validation error, got: %v", err)
+		}
+	})
+}
+
+// ─── In-place group-by-type ─────────────────────────────────────────────────
+
+func TestInlineGroupByType(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/grp", 0755)
+	_ = afero.WriteFile(memFS, "/grp/main.tf", []byte("variable \"region\" {\n  type = string\n}\n"), 0644)
+	_ = afero.WriteFile(memFS, "/grp/network.tf", []byte("# The VPC.\nresource \"aws_vpc\" \"main\" {\n  cidr_block = \"10.0.0.0/16\"\n}\n"), 0644)
+	_ = afero.WriteFile(memFS, "/grp/notes.tf", []byte("# Nothing here yet.\n"), 0644)
+
+	oldStderr := os.Stderr
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("could not create pipe: %v", err)
+	}
+	os.Stderr = w
+	runErr := NewSorter(&Params{Inline: true, GroupByType: true}, memFS).run("/grp")
+	w.Close()
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+	os.Stderr = oldStderr
+
+	if runErr != nil {
+		t.Fatalf("run() error: %v", runErr)
+	}
+	if want := "Modified /grp/main.tf\nRemoved /grp/network.tf\nCreated /grp/variables.tf\n"; buf.String() != want {
+		t.Errorf("stderr = %q, want %q", buf.String(), want)
+	}
+
+	main, _ := afero.ReadFile(memFS, "/grp/main.tf")
+	if want := "# The VPC.\nresource \"aws_vpc\" \"main\" {\n  cidr_block = \"10.0.0.0/16\"\n}\n"; string(main) != want {
+		t.Errorf("main.tf =\n%s\nwant:\n%s", main, want)
+	}
+	if exists, _ := afero.Exists(memFS, "/grp/network.tf"); exists {
+		t.Error("expected network.tf to be removed")
+	}
+	notes, _ := afero.ReadFile(memFS, "/grp/notes.tf")
+	if string(notes) != "# Nothing here yet.\n" {
+		t.Errorf("expected the comment-only file to be left alone, got %q", notes)
+	}
+
+	// A second run is a no-op.
+	if err := NewSorter(&Params{Check: true, GroupByType: true}, memFS).run("/grp"); err != nil {
+		t.Errorf("expected grouped module to pass check, got: %v", err)
+	}
+}
+
+func TestInlineGroupByTypeRefusesExcludedFile(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/grp", 0755)
+	_ = afero.WriteFile(memFS, "/grp/main.tf", []byte("variable \"region\" {\n  type = string\n}\n"), 0644)
+	_ = afero.WriteFile(memFS, "/grp/network.tf", []byte("# The VPC.\nresource \"aws_vpc\" \"main\" {\n  cidr_block = \"10.0.0.0/16\"\n}\n"), 0644)
+	_ = afero.WriteFile(memFS, "/grp/notes.tf", []byte("# Nothing here yet.\n"), 0644)
+	_ = afero.WriteFile(memFS, "/grp/variables.tf", []byte("variable \"generated\" {}\n"), 0644)
+
+	err := NewSorter(&Params{Inline: true, GroupByType: true, Excludes: []string{"variables.tf"}}, memFS).run("/grp")
+	if err == nil || !strings.Contains(err.Error(), "could not write /grp/variables.tf: the file exists and was not part of the input") {
+		t.Fatalf("expected refusal to overwrite the excluded file, got: %v", err)
+	}
+	if exists, _ := afero.Exists(memFS, "/grp/network.tf"); !exists {
+		t.Error("expected files to be left untouched on error")
+	}
+}
+
+// TestInlineGroupByTypeKeepsFilesSortedAlone verifies that a variable
+// definitions file without assignments is neither removed nor blocks the
+// in-place run.
+func TestInlineGroupByTypeKeepsFilesSortedAlone(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/grp", 0755)
+	_ = afero.WriteFile(memFS, "/grp/main.tf", []byte("variable \"region\" {\n  type = string\n}\n"), 0644)
+	_ = afero.WriteFile(memFS, "/grp/empty.auto.tfvars", []byte("# Nothing here yet.\n"), 0644)
+
+	if err := NewSorter(&Params{Inline: true, GroupByType: true}, memFS).run("/grp"); err != nil {
+		t.Fatalf("run() error: %v", err)
+	}
+	if exists, _ := afero.Exists(memFS, "/grp/main.tf"); exists {
+		t.Error("expected main.tf to be removed")
+	}
+	if exists, _ := afero.Exists(memFS, "/grp/variables.tf"); !exists {
+		t.Error("expected variables.tf to be written")
+	}
+	if exists, _ := afero.Exists(memFS, "/grp/empty.auto.tfvars"); !exists {
+		t.Error("expected empty.auto.tfvars to be kept")
+	}
+}
+
+func TestCheckModeGroupByTypeReportsRemovals(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/grp", 0755)
+	_ = afero.WriteFile(memFS, "/grp/main.tf", []byte("variable \"region\" {\n  type = string\n}\n"), 0644)
+	_ = afero.WriteFile(memFS, "/grp/network.tf", []byte("# The VPC.\nresource \"aws_vpc\" \"main\" {\n  cidr_block = \"10.0.0.0/16\"\n}\n"), 0644)
+	_ = afero.WriteFile(memFS, "/grp/notes.tf", []byte("# Nothing here yet.\n"), 0644)
+	s := NewSorter(&Params{Check: true, GroupByType: true, ReportFormat: reportJSON, ReportFile: "/out/report.json"}, memFS)
+	err := s.run("/grp")
+	if !errors.Is(err, ErrCheckFailed) || !strings.Contains(err.Error(), "/grp/network.tf") {
+		t.Fatalf("expected check failure listing network.tf, got: %v", err)
+	}
+
+	content, _ := afero.ReadFile(memFS, "/out/report.json")
+	if !strings.Contains(string(content), `"path": "/grp/network.tf",`+"\n"+`      "status": "removed"`) {
+		t.Errorf("expected network.tf to be reported as removed, got:\n%s", content)
+	}
+	if exists, _ := afero.Exists(memFS, "/grp/network.tf"); !exists {
+		t.Error("check mode removed a file")
+	}
+}
+
+// TestDiffGroupByTypeRemovedFile verifies that --diff --group-by-type shows
+// a file whose blocks all move elsewhere as deleted.
+func TestDiffGroupByTypeRemovedFile(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/grp", 0755)
+	_ = afero.WriteFile(memFS, "/grp/main.tf", []byte("variable \"region\" {\n  type = string\n}\n"), 0644)
+	_ = afero.WriteFile(memFS, "/grp/network.tf", []byte("# The VPC.\nresource \"aws_vpc\" \"main\" {\n  cidr_block = \"10.0.0.0/16\"\n}\n"), 0644)
+	_ = afero.WriteFile(memFS, "/grp/notes.tf", []byte("# Nothing here yet.\n"), 0644)
+
+	oldStdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("could not create pipe: %v", err)
+	}
+	os.Stdout = w
+	runErr := NewSorter(&Params{Diff: true, Check: true, GroupByType: true, Color: colorNever}, memFS).run("/grp")
+	w.Close()
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+	os.Stdout = oldStdout
+
+	if !errors.Is(runErr, ErrCheckFailed) {
+		t.Fatalf("expected ErrCheckFailed, got: %v", runErr)
+	}
+	want := "--- /grp/network.tf\n+++ " + os.DevNull + "\n@@ -1,4 +1,0 @@\n-# The VPC.\n"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("expected deletion diff %q, got:\n%s", want, buf.String())
+	}
+}
@@ -11,6 +11,13 @@ import (
 // flags holds the CLI flags for the Sort command
 var flags = &Params{}
 
+// splitStrategy and mergeFile hold the arguments of the split and merge
+// commands that are not sort settings.
+var (
+	splitStrategy string
+	mergeFile     string
+)
+
 func GetCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Args: cobra.ArbitraryArgs,
@@ -51,6 +58,152 @@ When the argument is "-" or omitted and stdin is piped, input is read from stdin
 	return cmd
 }
 
+// GetLintCommand returns the lint command, which reports ordering
+// violations without changing any file.
+func GetLintCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Args: cobra.MinimumNArgs(1),
+		Example: `  tforganize lint main.tf
+  tforganize lint --group-by-type ./terraform/`,
+		Long: `Lint reads a Terraform file or folder and reports every block and argument that sort would move, as file:line:column messages.
+
+Lint uses the same ordering rules and settings as sort and exits with status 2 when it finds a violation.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Violations are not usage errors.
+			cmd.SilenceUsage = true
+
+			count := 0
+			for _, target := range args {
+				violations, err := Lint(target, flags)
+				if err != nil {
+					return err
+				}
+				for _, v := range violations {
+					fmt.Println(v)
+				}
+				count += len(violations)
+			}
+			if count > 0 {
+				return fmt.Errorf("%w: %d found", ErrLintFailed, count)
+			}
+			return nil
+		},
+		Short: "Report ordering violations in a Terraform file or folder.",
+		Use:   "lint [file | folder] ...",
+	}
+
+	setGroupFlags(cmd)
+	setRecursiveFlag(cmd)
+	setRuleFlags(cmd)
+
+	return cmd
+}
+
+// GetRenameCommand returns the rename command, which renames a resource or
+// module call and records the rename in a moved block.
+func GetRenameCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Args: cobra.ExactArgs(3),
+		Example: `  tforganize rename aws_iam_role.old aws_iam_role.app ./terraform/
+  tforganize rename module.network module.vpc .`,
+		Long: `Rename renames a resource or module call in the Terraform module in a folder.
+
+It rewrites the labels of the declaring block and every reference to the old address in the module's files, appends a moved block from the old address to the new one, and then sorts the changed files in place with the current settings.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := Rename(args[0], args[1], args[2], flags)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Renamed %s to %s (%s in %s)\n", args[0], args[1],
+				plural(result.References, "reference", "references"), plural(len(result.Files), "file", "files"))
+			return nil
+		},
+		Short: "Rename a resource or module call and add a moved block.",
+		Use:   "rename <from-address> <to-address> <folder>",
+	}
+
+	setFormatFlags(cmd)
+	setRuleFlags(cmd)
+
+	return cmd
+}
+
+// GetSplitCommand returns the split command, which spreads the files of a
+// module over one file per block type, resource type or name prefix.
+func GetSplitCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Args: cobra.ExactArgs(1),
+		Example: `  tforganize split ./terraform/
+  tforganize split --strategy by-resource-type ./terraform/
+  tforganize split --strategy by-prefix --output-dir ./split/ .`,
+		Long: `Split restructures the Terraform module in a folder into one file per group of blocks.
+
+The by-type strategy (the default) uses the group-by-type file mapping, including file-groups and file-rules. by-resource-type writes resource and data blocks to a file named after their type, such as aws_iam_role.tf, and by-prefix writes resource, data and module blocks to a file named after the part of their name before the first underscore or hyphen, such as network.tf for aws_vpc.network_main. Other blocks follow the by-type mapping. Comments stay with their blocks, and files whose blocks all moved elsewhere are deleted.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := Split(args[0], splitStrategy, flags)
+			if err != nil {
+				return err
+			}
+			printLayoutResult(result)
+			return nil
+		},
+		Short: "Split a Terraform folder into one file per block group.",
+		Use:   "split <folder>",
+	}
+
+	cmd.PersistentFlags().StringVar(&splitStrategy, "strategy", splitByType, "how blocks are grouped into files: by-type, by-resource-type or by-prefix")
+	setLayoutFlags(cmd)
+
+	return cmd
+}
+
+// GetMergeCommand returns the merge command, which collapses the files of a
+// module into one file.
+func GetMergeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Args: cobra.ExactArgs(1),
+		Example: `  tforganize merge ./examples/basic/
+  tforganize merge --file example.tf --output-dir ./registry/ .`,
+		Long: `Merge collapses the Terraform module in a folder into a single sorted file.
+
+Comments stay with their blocks, and the files whose blocks moved into the merged file are deleted.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := Merge(args[0], mergeFile, flags)
+			if err != nil {
+				return err
+			}
+			printLayoutResult(result)
+			return nil
+		},
+		Short: "Merge the files of a Terraform folder into one file.",
+		Use:   "merge <folder>",
+	}
+
+	cmd.PersistentFlags().StringVar(&mergeFile, "file", "main.tf", "name of the merged file")
+	setLayoutFlags(cmd)
+
+	return cmd
+}
+
+// setLayoutFlags registers the flags shared by the split and merge commands.
+// Both restructure a single folder, so recursive is not registered.
+func setLayoutFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&flags.OutputDir, "output-dir", "o", "", "write the files to a specific folder instead of restructuring the folder in place")
+	setFormatFlags(cmd)
+	setGroupFlags(cmd)
+	setRuleFlags(cmd)
+}
+
+// printLayoutResult lists the files written and removed by split or merge.
+func printLayoutResult(result *LayoutResult) {
+	for _, f := range result.Written {
+		fmt.Printf("Wrote %s\n", f)
+	}
+	for _, f := range result.Removed {
+		fmt.Printf("Removed %s\n", f)
+	}
+}
+
 func sortStdin(flags *Params) error {
 	if flags.Inline {
 		return fmt.Errorf("the --inline flag cannot be used with stdin")
@@ -68,19 +221,67 @@ func sortStdin(flags *Params) error {
 }
 
 func setFlags(cmd *cobra.Command) {
-	cmd.PersistentFlags().BoolVarP(&flags.GroupByType, "group-by-type", "g", false, "organize the resources by type in the output files")
+	cmd.PersistentFlags().StringVar(&flags.DuplicateBlocks, "duplicate-blocks", "warn", "what to do when two blocks share an address: warn, error or off")
+	cmd.PersistentFlags().BoolVarP(&flags.Inline, "inline", "i", false, "sort the resources in the input file(s) in place")
+	cmd.PersistentFlags().StringVarP(&flags.OutputDir, "output-dir", "o", "", "output the results to a specific folder")
+	cmd.PersistentFlags().BoolVarP(&flags.Check, "check", "c", false, "check whether files are already sorted without writing changes; exits non-zero if any file would change")
+	cmd.PersistentFlags().BoolVar(&flags.Diff, "diff", false, "show a unified diff of changes instead of writing files")
+	cmd.PersistentFlags().BoolVar(&flags.Summary, "summary", false, "print a block-level summary of moved blocks and reordered arguments instead of the sorted files")
+	cmd.PersistentFlags().StringVar(&flags.Plan, "plan", "", "print the source and destination of every block as text or json instead of the sorted files")
+	cmd.PersistentFlags().BoolVar(&flags.Manifest, "manifest", false, "write the move plan as JSON to "+manifestFileName+" in the output directory")
+	cmd.PersistentFlags().StringVar(&flags.Color, "color", "auto", "color diffs: auto (when stdout is a terminal and NO_COLOR is empty or unset), always or never")
+	cmd.PersistentFlags().BoolVar(&flags.ColorMoved, "color-moved", false, "dim moved lines in colored diffs instead of showing them as deleted and added")
+	cmd.PersistentFlags().StringVar(&flags.ReportFormat, "report-format", "text", "per-file report format: text, json, sarif, junit, checkstyle, github or gitlab-codequality")
+	cmd.PersistentFlags().StringVar(&flags.ReportFile, "report-file", "", "write the per-file report to this path instead of stdout")
+
+	setFormatFlags(cmd)
+	setGroupFlags(cmd)
+	setRecursiveFlag(cmd)
+	setRuleFlags(cmd)
+}
+
+// setFormatFlags registers the flags that shape the sorted output. They are
+// shared by the sort, rename, split and merge commands.
+func setFormatFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolVar(&flags.MergeBlocks, "merge-blocks", false, "fold multiple locals and terraform blocks in an output file into one, merging required_providers")
 	cmd.PersistentFlags().BoolVarP(&flags.HasHeader, "has-header", "e", false, "the input files have a header")
 	cmd.PersistentFlags().StringVarP(&flags.HeaderPattern, "header-pattern", "p", "", "the header pattern to find the header in the input files")
 	cmd.PersistentFlags().StringVar(&flags.HeaderEndPattern, "header-end-pattern", "", "pattern marking the end of a multi-line header block (e.g. '**/' or '*/')")
 	cmd.PersistentFlags().BoolVarP(&flags.KeepHeader, "keep-header", "k", false, "keep the header matched in the header pattern in the output files")
-	cmd.PersistentFlags().BoolVarP(&flags.Inline, "inline", "i", false, "sort the resources in the input file(s) in place")
-	cmd.PersistentFlags().StringVarP(&flags.OutputDir, "output-dir", "o", "", "output the results to a specific folder")
 	cmd.PersistentFlags().BoolVarP(&flags.RemoveComments, "remove-comments", "r", false, "remove comments in the sorted file(s)")
-	cmd.PersistentFlags().BoolVarP(&flags.Check, "check", "c", false, "check whether files are already sorted without writing changes; exits non-zero if any file would change")
-	cmd.PersistentFlags().BoolVarP(&flags.Recursive, "recursive", "R", false, "recursively sort all nested directories containing .tf files")
-	cmd.PersistentFlags().BoolVar(&flags.Diff, "diff", false, "show a unified diff of changes instead of writing files")
-	cmd.PersistentFlags().BoolVar(&flags.NoSortByType, "no-sort-by-type", false, "sort blocks alphabetically by type instead of using logical type ordering")
+	cmd.PersistentFlags().BoolVar(&flags.SortObjectKeys, "sort-object-keys", false, "sort the keys of object literals in allowlisted arguments and canonicalise required_providers entries")
+	cmd.PersistentFlags().StringSliceVar(&flags.ObjectKeyArguments, "object-key-arguments", []string{}, "argument names whose object values are key-sorted by --sort-object-keys (default labels,tags,type)")
+	cmd.PersistentFlags().BoolVar(&flags.SortLists, "sort-lists", false, "sort order-insensitive list literals such as depends_on, lifecycle.ignore_changes and toset([...])")
+	cmd.PersistentFlags().StringSliceVar(&flags.ListPaths, "list-paths", []string{}, "argument paths whose lists are sorted by --sort-lists (e.g. resource.*.lifecycle.ignore_changes)")
 	cmd.PersistentFlags().BoolVar(&flags.StripSectionComments, "strip-section-comments", false, "remove section-divider comments (e.g. # === Section ===, # ---) from the output")
 	cmd.PersistentFlags().BoolVar(&flags.CompactEmptyBlocks, "compact-empty-blocks", false, "collapse empty blocks to a single line (e.g. data \"aws_region\" \"current\" {})")
+}
+
+// setGroupFlags registers the flags that move blocks between files. The
+// rename command does not register them, since it keeps every block in its
+// file.
+func setGroupFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolVarP(&flags.GroupByType, "group-by-type", "g", false, "organize the resources by type in the output files")
+	cmd.PersistentFlags().StringToStringVar(&flags.FileGroups, "file-groups", map[string]string{}, "override the group-by-type file for a block type (e.g. provider=providers.tf,default=main.tf)")
+}
+
+// setRecursiveFlag registers the recursive flag for the commands that walk
+// nested directories.
+func setRecursiveFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolVarP(&flags.Recursive, "recursive", "R", false, "recursively sort all nested directories containing .tf files")
+}
+
+// setRuleFlags registers the flags that decide the expected order. They are
+// shared by the sort, lint, rename, split and merge commands.
+func setRuleFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolVar(&flags.NoSortByType, "no-sort-by-type", false, "sort blocks alphabetically by type instead of using logical type ordering")
+	cmd.PersistentFlags().StringSliceVar(&flags.BlockOrder, "block-order", []string{}, "comma-separated top-level block type order (e.g. terraform,variable,module,resource); unlisted types follow in the default order")
+	cmd.PersistentFlags().StringToStringVar(&flags.BlockComparators, "block-comparators", map[string]string{}, "per-type label ordering: alphabetical, preserve-original or label:<n> (e.g. output=preserve-original)")
+	cmd.PersistentFlags().StringVar(&flags.Collation, "collation", "byte", "label ordering: byte, natural (numeric-aware) or case-insensitive")
+	cmd.PersistentFlags().StringVar(&flags.DynamicBlocks, "dynamic-blocks", "separate", "where dynamic blocks sort: separate, static-first or dynamic-first (next to static blocks of the same type)")
+	cmd.PersistentFlags().StringVar(&flags.VarsOrder, "vars-order", "alphabetical", "order of .tfvars assignments: alphabetical or declaration (the order of the variable blocks in the same directory)")
+	cmd.PersistentFlags().BoolVar(&flags.OrderByDependencies, "order-by-dependencies", false, "order locals and same-type resources so that definitions come before their uses")
+	cmd.PersistentFlags().StringVar(&flags.Scope, "scope", "all", "what to sort: all, blocks (top-level block order only) or bodies (block contents only)")
+	cmd.PersistentFlags().IntVar(&flags.BodyDepth, "body-depth", 0, "maximum nesting depth of block bodies to sort (0 = unlimited)")
 	cmd.PersistentFlags().StringArrayVarP(&flags.Excludes, "exclude", "x", []string{}, "glob pattern to exclude from sorting (repeatable; supports **); e.g. --exclude '.terraform/**'")
 }
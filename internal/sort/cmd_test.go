@@ -6,6 +6,8 @@ import (
 	"os"
 	"strings"
 	"testing"
+
+	"github.com/spf13/cobra"
 )
 
 func TestGetCommand(t *testing.T) {
@@ -31,6 +33,14 @@ func TestGetCommand(t *testing.T) {
 		"strip-section-comments",
 		"compact-empty-blocks",
 		"exclude",
+		"block-order",
+		"block-comparators",
+		"file-groups",
+		"collation",
+		"order-by-dependencies",
+		"scope",
+		"body-depth",
+		"dynamic-blocks",
 	}
 
 	for _, flag := range expectedFlags {
@@ -40,6 +50,30 @@ func TestGetCommand(t *testing.T) {
 	}
 }
 
+func TestGetRenameCommandFlags(t *testing.T) {
+	cmd := GetRenameCommand()
+
+	for _, flag := range []string{"group-by-type", "file-groups", "recursive"} {
+		if cmd.PersistentFlags().Lookup(flag) != nil {
+			t.Errorf("rename ignores %q and must not register it", flag)
+		}
+	}
+	if cmd.PersistentFlags().Lookup("block-order") == nil {
+		t.Error("expected persistent flag \"block-order\" to be registered")
+	}
+}
+
+func TestGetLayoutCommandFlags(t *testing.T) {
+	for _, cmd := range []*cobra.Command{GetSplitCommand(), GetMergeCommand()} {
+		if cmd.PersistentFlags().Lookup("recursive") != nil {
+			t.Errorf("%s rejects \"recursive\" and must not register it", cmd.Name())
+		}
+		if cmd.PersistentFlags().Lookup("file-groups") == nil {
+			t.Errorf("expected %s to register \"file-groups\"", cmd.Name())
+		}
+	}
+}
+
 func TestSortStdinInlineError(t *testing.T) {
 	err := sortStdin(&Params{Inline: true})
 	if err == nil {
@@ -0,0 +1,106 @@
+package sort
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Collation names accepted by the collation setting.
+const (
+	collationByte            = "byte"
+	collationNatural         = "natural"
+	collationCaseInsensitive = "case-insensitive"
+)
+
+// collation selects how labels and block keys are compared. The zero value
+// compares strings byte by byte, which is the historical behaviour.
+type collation int
+
+const (
+	byteCollation collation = iota
+	naturalCollation
+	caseInsensitiveCollation
+)
+
+// parseCollation parses the collation setting. An empty value selects the
+// byte collation.
+func parseCollation(value string) (collation, error) {
+	switch value {
+	case "", collationByte:
+		return byteCollation, nil
+	case collationNatural:
+		return naturalCollation, nil
+	case collationCaseInsensitive:
+		return caseInsensitiveCollation, nil
+	}
+	return byteCollation, fmt.Errorf("unknown collation %q (expected %s, %s or %s)",
+		value, collationByte, collationNatural, collationCaseInsensitive)
+}
+
+// less reports whether a sorts before b. Strings that are equal under the
+// natural or case-insensitive collation fall back to a byte comparison so
+// the ordering stays deterministic.
+func (c collation) less(a, b string) bool {
+	switch c {
+	case naturalCollation:
+		if cmp := naturalCompare(a, b); cmp != 0 {
+			return cmp < 0
+		}
+	case caseInsensitiveCollation:
+		if la, lb := strings.ToLower(a), strings.ToLower(b); la != lb {
+			return la < lb
+		}
+	}
+	return a < b
+}
+
+// naturalCompare compares a and b treating runs of ASCII digits as numbers,
+// so that "subnet_2" sorts before "subnet_10". It returns -1, 0 or 1.
+func naturalCompare(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			// Compare the digit runs by value: skip leading zeros, then the
+			// longer run is larger, then compare digit by digit.
+			si, sj := i, j
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+			numA := strings.TrimLeft(a[si:i], "0")
+			numB := strings.TrimLeft(b[sj:j], "0")
+			if len(numA) != len(numB) {
+				return compareInts(len(numA), len(numB))
+			}
+			if numA != numB {
+				return strings.Compare(numA, numB)
+			}
+			continue
+		}
+		if a[i] != b[j] {
+			return compareInts(int(a[i]), int(b[j]))
+		}
+		i++
+		j++
+	}
+	return compareInts(len(a)-i, len(b)-j)
+}
+
+// isDigit reports whether c is an ASCII digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// compareInts returns -1, 0 or 1 depending on whether a is less than, equal
+// to or greater than b.
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,144 @@
+package sort
+
+import (
+	"reflect"
+	gosort "sort"
+	"strings"
+	"testing"
+
+	hclsyntax "github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestParseCollation(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    collation
+		wantErr bool
+	}{
+		{value: "", want: byteCollation},
+		{value: "byte", want: byteCollation},
+		{value: "natural", want: naturalCollation},
+		{value: "case-insensitive", want: caseInsensitiveCollation},
+		{value: "locale", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseCollation(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCollation(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseCollation(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollationLess(t *testing.T) {
+	input := []string{"subnet_10", "Zone", "subnet_2", "apple", "subnet_02", "subnet_1a"}
+
+	tests := []struct {
+		name      string
+		collation collation
+		want      []string
+	}{
+		{
+			name:      "byte",
+			collation: byteCollation,
+			want:      []string{"Zone", "apple", "subnet_02", "subnet_10", "subnet_1a", "subnet_2"},
+		},
+		{
+			name:      "natural",
+			collation: naturalCollation,
+			want:      []string{"Zone", "apple", "subnet_1a", "subnet_02", "subnet_2", "subnet_10"},
+		},
+		{
+			name:      "case-insensitive",
+			collation: caseInsensitiveCollation,
+			want:      []string{"apple", "subnet_02", "subnet_10", "subnet_1a", "subnet_2", "Zone"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := append([]string{}, input...)
+			gosort.Slice(got, func(i, j int) bool { return tt.collation.less(got[i], got[j]) })
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNaturalCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"subnet_2", "subnet_10", -1},
+		{"subnet_10", "subnet_2", 1},
+		{"subnet_2", "subnet_2", 0},
+		{"subnet_02", "subnet_2", 0},
+		{"a", "a1", -1},
+		{"v1.9", "v1.10", -1},
+	}
+
+	for _, tt := range tests {
+		if got := naturalCompare(tt.a, tt.b); got != tt.want {
+			t.Errorf("naturalCompare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestBlockListSorterCollation(t *testing.T) {
+	bs := BlockListSorter{
+		blocks: []*hclsyntax.Block{
+			{Type: "resource", Labels: []string{"aws_subnet", "subnet_10"}},
+			{Type: "resource", Labels: []string{"aws_subnet", "subnet_2"}},
+		},
+		sortByType: true,
+		collation:  naturalCollation,
+	}
+	if !bs.Less(1, 0) {
+		t.Error("subnet_2 should come before subnet_10 with natural collation")
+	}
+}
+
+// TestSortBytesNaturalCollation verifies that the collation applies to both
+// top-level labels and nested block keys.
+func TestSortBytesNaturalCollation(t *testing.T) {
+	input := []byte(`resource "aws_subnet" "subnet_10" {
+  cidr_block = "10.0.10.0/24"
+}
+
+resource "aws_subnet" "subnet_2" {
+  cidr_block = "10.0.2.0/24"
+}
+
+resource "aws_instance" "web" {
+  disk_10 {
+    size = 10
+  }
+  disk_2 {
+    size = 2
+  }
+}
+`)
+	result, err := SortBytes(input, "main.tf", &Params{Collation: "natural"})
+	if err != nil {
+		t.Fatalf("SortBytes returned unexpected error: %v", err)
+	}
+
+	out := string(result)
+	if strings.Index(out, `"subnet_2"`) > strings.Index(out, `"subnet_10"`) {
+		t.Errorf("subnet_2 should come before subnet_10:\n%s", out)
+	}
+	if strings.Index(out, "disk_2") > strings.Index(out, "disk_10") {
+		t.Errorf("disk_2 should come before disk_10:\n%s", out)
+	}
+
+	if _, err := SortBytes(input, "main.tf", &Params{Collation: "unknown"}); err == nil {
+		t.Error("expected an error for an unknown collation, got nil")
+	}
+}
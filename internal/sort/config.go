@@ -0,0 +1,48 @@
+package sort
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// BindConfig loads the structured settings that have no flag equivalent
+// (lists of objects and nested maps) from the configuration file into the
+// sort command's parameters. Scalar and list settings are bound through
+// their flags instead.
+func BindConfig(v *viper.Viper) error {
+	return bindStructuredConfig(v, flags)
+}
+
+// bindStructuredConfig decodes each structured key present in v into p.
+func bindStructuredConfig(v *viper.Viper, p *Params) error {
+	keys := map[string]interface{}{
+		"argument-profiles": &p.ArgumentProfiles,
+		"file-rules":        &p.FileRules,
+		"meta-arguments":    &p.MetaArguments,
+	}
+
+	for key, out := range keys {
+		if !v.IsSet(key) {
+			continue
+		}
+		if err := decodeConfigKey(v, key, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeConfigKey decodes the value stored under key into out. The value is
+// round-tripped through YAML so the yaml struct tags used by Params apply.
+func decodeConfigKey(v *viper.Viper, key string, out interface{}) error {
+	raw, err := yaml.Marshal(v.Get(key))
+	if err != nil {
+		return fmt.Errorf("could not read %s setting: %w", key, err)
+	}
+	if err := yaml.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("invalid %s setting: %w", key, err)
+	}
+	return nil
+}
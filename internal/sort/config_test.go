@@ -0,0 +1,85 @@
+package sort
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestBindStructuredConfig(t *testing.T) {
+	t.Run("decodes file rules", func(t *testing.T) {
+		v := viper.New()
+		v.SetConfigType("yaml")
+		if err := v.ReadConfig(strings.NewReader(`
+file-rules:
+  - type: resource
+    labels: ["aws_iam_*"]
+    file: iam.tf
+  - annotation: "tforganize:file=dns.tf"
+    file: dns.tf
+`)); err != nil {
+			t.Fatal(err)
+		}
+
+		p := &Params{}
+		if err := bindStructuredConfig(v, p); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := []FileRule{
+			{Type: "resource", Labels: []string{"aws_iam_*"}, File: "iam.tf"},
+			{Annotation: "tforganize:file=dns.tf", File: "dns.tf"},
+		}
+		if !reflect.DeepEqual(p.FileRules, want) {
+			t.Errorf("got %+v, want %+v", p.FileRules, want)
+		}
+	})
+
+	t.Run("decodes meta arguments", func(t *testing.T) {
+		v := viper.New()
+		v.SetConfigType("yaml")
+		if err := v.ReadConfig(strings.NewReader(`
+meta-arguments:
+  resource:
+    post: [provisioner, lifecycle, depends_on, tags]
+  module:
+    pre: [source, version, providers]
+`)); err != nil {
+			t.Fatal(err)
+		}
+
+		p := &Params{}
+		if err := bindStructuredConfig(v, p); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := map[string]MetaArgumentList{
+			"resource": {Post: []string{"provisioner", "lifecycle", "depends_on", "tags"}},
+			"module":   {Pre: []string{"source", "version", "providers"}},
+		}
+		if !reflect.DeepEqual(p.MetaArguments, want) {
+			t.Errorf("got %+v, want %+v", p.MetaArguments, want)
+		}
+	})
+
+	t.Run("unset keys are left alone", func(t *testing.T) {
+		p := &Params{FileRules: []FileRule{{Type: "provider", File: "providers.tf"}}}
+		if err := bindStructuredConfig(viper.New(), p); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(p.FileRules) != 1 {
+			t.Errorf("expected existing rules to be kept, got %+v", p.FileRules)
+		}
+	})
+
+	t.Run("malformed value is reported", func(t *testing.T) {
+		v := viper.New()
+		v.Set("file-rules", "not-a-list")
+		err := bindStructuredConfig(v, &Params{})
+		if err == nil || !strings.Contains(err.Error(), "invalid file-rules setting") {
+			t.Fatalf("expected invalid file-rules error, got: %v", err)
+		}
+	})
+}
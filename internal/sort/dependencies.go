@@ -0,0 +1,196 @@
+package sort
+
+import (
+	hcl "github.com/hashicorp/hcl/v2"
+	hclsyntax "github.com/hashicorp/hcl/v2/hclsyntax"
+	log "github.com/sirupsen/logrus"
+)
+
+// topologicalOrder orders the nodes 0..n-1 so that every node comes after the
+// nodes it depends on. Among the nodes that are ready at the same time the
+// lowest index is taken first, so callers pass nodes in their alphabetical
+// order to get alphabetical tie-breaking. It returns false when the graph has
+// a cycle.
+func topologicalOrder(n int, deps [][]int) ([]int, bool) {
+	pending := make([]int, n)      // number of unresolved dependencies
+	dependents := make([][]int, n) // reverse edges
+	for node, nodeDeps := range deps {
+		for _, dep := range nodeDeps {
+			if dep == node {
+				continue
+			}
+			pending[node]++
+			dependents[dep] = append(dependents[dep], node)
+		}
+	}
+
+	done := make([]bool, n)
+	order := make([]int, 0, n)
+	for len(order) < n {
+		next := -1
+		for node := 0; node < n; node++ {
+			if !done[node] && pending[node] == 0 {
+				next = node
+				break
+			}
+		}
+		if next == -1 {
+			return nil, false
+		}
+		done[next] = true
+		order = append(order, next)
+		for _, dependent := range dependents[next] {
+			pending[dependent]--
+		}
+	}
+	return order, true
+}
+
+// bodyTraversals returns every variable traversal in a body, including those
+// in nested blocks.
+func bodyTraversals(body *hclsyntax.Body) []hcl.Traversal {
+	var traversals []hcl.Traversal
+	for _, attribute := range body.Attributes {
+		traversals = append(traversals, attribute.Expr.Variables()...)
+	}
+	for _, block := range body.Blocks {
+		traversals = append(traversals, bodyTraversals(block.Body)...)
+	}
+	return traversals
+}
+
+// traversalAttrNames returns the root name of a traversal followed by the
+// names of its leading attribute steps (e.g. data.aws_ami.ubuntu.id gives
+// ["data", "aws_ami", "ubuntu", "id"]).
+func traversalAttrNames(traversal hcl.Traversal) []string {
+	names := []string{traversal.RootName()}
+	for _, step := range traversal[1:] {
+		attr, ok := step.(hcl.TraverseAttr)
+		if !ok {
+			break
+		}
+		names = append(names, attr.Name)
+	}
+	return names
+}
+
+// blockAddress returns the reference address of a resource or data block
+// (e.g. "aws_instance.web" or "data.aws_ami.ubuntu"), or "" for other blocks.
+func blockAddress(block *hclsyntax.Block) string {
+	switch {
+	case block.Type == "resource" && len(block.Labels) == 2:
+		return block.Labels[0] + "." + block.Labels[1]
+	case block.Type == "data" && len(block.Labels) == 2:
+		return "data." + block.Labels[0] + "." + block.Labels[1]
+	}
+	return ""
+}
+
+// referencedAddresses returns the resource and data addresses a traversal
+// could refer to.
+func referencedAddresses(traversal hcl.Traversal) []string {
+	names := traversalAttrNames(traversal)
+	var addresses []string
+	if len(names) >= 2 {
+		addresses = append(addresses, names[0]+"."+names[1])
+	}
+	if len(names) >= 3 && names[0] == "data" {
+		addresses = append(addresses, "data."+names[1]+"."+names[2])
+	}
+	return addresses
+}
+
+// orderBlocksByDependencies reorders each run of same-type resource or data
+// blocks so that referenced blocks come before the blocks that use them. The
+// incoming order is kept for blocks without a dependency between them. A run
+// with a dependency cycle is left in its incoming order and a warning logged.
+func orderBlocksByDependencies(blocks hclsyntax.Blocks) {
+	for start := 0; start < len(blocks); {
+		end := start + 1
+		for end < len(blocks) && blocks[end].Type == blocks[start].Type {
+			end++
+		}
+		if blocks[start].Type == "resource" || blocks[start].Type == "data" {
+			orderBlockRunByDependencies(blocks[start:end])
+		}
+		start = end
+	}
+}
+
+// orderBlockRunByDependencies applies dependency ordering to a run of blocks
+// of the same type.
+func orderBlockRunByDependencies(run hclsyntax.Blocks) {
+	if len(run) < 2 {
+		return
+	}
+
+	index := make(map[string]int, len(run))
+	for i, block := range run {
+		if address := blockAddress(block); address != "" {
+			index[address] = i
+		}
+	}
+
+	deps := make([][]int, len(run))
+	for i, block := range run {
+		for _, traversal := range bodyTraversals(block.Body) {
+			for _, address := range referencedAddresses(traversal) {
+				if j, ok := index[address]; ok {
+					deps[i] = append(deps[i], j)
+				}
+			}
+		}
+	}
+
+	order, ok := topologicalOrder(len(run), deps)
+	if !ok {
+		log.WithField(blockTypeLabel, run[0].Type).Warnln("Dependency cycle between blocks; keeping alphabetical order")
+		return
+	}
+
+	sorted := make(hclsyntax.Blocks, len(run))
+	for i, j := range order {
+		sorted[i] = run[j]
+	}
+	copy(run, sorted)
+}
+
+// orderLocalsByDependencies reorders the attribute keys of a locals block so
+// that each local is defined before the locals that reference it. keys must
+// be sorted alphabetically; that order breaks ties. On a cycle keys are
+// returned unchanged and a warning logged.
+func orderLocalsByDependencies(block *hclsyntax.Block, keys []string) []string {
+	index := make(map[string]int, len(keys))
+	for i, key := range keys {
+		index[key] = i
+	}
+
+	deps := make([][]int, len(keys))
+	for i, key := range keys {
+		attribute, ok := block.Body.Attributes[key]
+		if !ok {
+			continue
+		}
+		for _, traversal := range attribute.Expr.Variables() {
+			names := traversalAttrNames(traversal)
+			if len(names) < 2 || names[0] != "local" {
+				continue
+			}
+			if j, ok := index[names[1]]; ok {
+				deps[i] = append(deps[i], j)
+			}
+		}
+	}
+
+	order, ok := topologicalOrder(len(keys), deps)
+	if !ok {
+		log.WithField(blockTypeLabel, block.Type).Warnln("Dependency cycle between locals; keeping alphabetical order")
+		return keys
+	}
+
+	sorted := make([]string, len(keys))
+	for i, j := range order {
+		sorted[i] = keys[j]
+	}
+	return sorted
+}
@@ -0,0 +1,113 @@
+package sort
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTopologicalOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		n      int
+		deps   [][]int
+		want   []int
+		wantOK bool
+	}{
+		{name: "no dependencies keeps order", n: 3, deps: make([][]int, 3), want: []int{0, 1, 2}, wantOK: true},
+		{name: "dependency moves forward", n: 3, deps: [][]int{{2}, nil, nil}, want: []int{1, 2, 0}, wantOK: true},
+		{name: "chain", n: 3, deps: [][]int{{1}, {2}, nil}, want: []int{2, 1, 0}, wantOK: true},
+		{name: "self reference ignored", n: 2, deps: [][]int{{0}, nil}, want: []int{0, 1}, wantOK: true},
+		{name: "cycle", n: 2, deps: [][]int{{1}, {0}}, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := topologicalOrder(tt.n, tt.deps)
+			if ok != tt.wantOK {
+				t.Fatalf("topologicalOrder ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("topologicalOrder = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSortBytesOrderByDependencies verifies that locals and same-type
+// resources are ordered so that definitions come before their uses.
+func TestSortBytesOrderByDependencies(t *testing.T) {
+	input := []byte(`locals {
+  a_name   = "${local.prefix}-app"
+  b_tags   = { Name = local.a_name }
+  z_region = "us-east-1"
+  prefix   = "${local.z_region}-prod"
+}
+
+resource "aws_subnet" "a" {
+  vpc_id = aws_vpc.main.id
+}
+
+resource "aws_instance" "web" {
+  subnet_id = aws_subnet.a.id
+  ami       = data.aws_ami.ubuntu.id
+}
+
+resource "aws_vpc" "main" {
+  cidr_block = "10.0.0.0/16"
+}
+
+data "aws_ami" "ubuntu" {
+  most_recent = true
+}
+`)
+	result, err := SortBytes(input, "main.tf", &Params{OrderByDependencies: true})
+	if err != nil {
+		t.Fatalf("SortBytes returned unexpected error: %v", err)
+	}
+	out := string(result)
+
+	order := []string{
+		"z_region", "prefix", "a_name", "b_tags",
+		`data "aws_ami" "ubuntu"`,
+		`resource "aws_vpc" "main"`, `resource "aws_subnet" "a"`, `resource "aws_instance" "web"`,
+	}
+	last := -1
+	for _, want := range order {
+		idx := strings.Index(out, want)
+		if idx == -1 {
+			t.Fatalf("expected %q in output, got:\n%s", want, out)
+		}
+		if idx < last {
+			t.Errorf("%q is out of order in output:\n%s", want, out)
+		}
+		last = idx
+	}
+
+	t.Run("disabled keeps alphabetical order", func(t *testing.T) {
+		result, err := SortBytes(input, "main.tf", &Params{})
+		if err != nil {
+			t.Fatalf("SortBytes returned unexpected error: %v", err)
+		}
+		out := string(result)
+		if strings.Index(out, "a_name") > strings.Index(out, "z_region") {
+			t.Errorf("expected alphabetical locals without order-by-dependencies:\n%s", out)
+		}
+	})
+
+	t.Run("cycle falls back to alphabetical", func(t *testing.T) {
+		cyclic := []byte(`locals {
+  b = local.a
+  a = local.b
+}
+`)
+		result, err := SortBytes(cyclic, "main.tf", &Params{OrderByDependencies: true})
+		if err != nil {
+			t.Fatalf("SortBytes returned unexpected error: %v", err)
+		}
+		out := string(result)
+		if strings.Index(out, "a = local.b") > strings.Index(out, "b = local.a") {
+			t.Errorf("expected alphabetical order for cyclic locals:\n%s", out)
+		}
+	})
+}
@@ -15,7 +15,6 @@ func unifiedDiff(aName, bName, a, b string) string {
 	aLines := splitLines(a)
 	bLines := splitLines(b)
 
-	// Simple Myers-like diff using the longest common subsequence.
 	edits := computeEdits(aLines, bLines)
 
 	var buf strings.Builder
@@ -58,60 +57,245 @@ func splitLines(s string) []string {
 	return lines
 }
 
-// computeEdits produces an edit script using a simple O(NM) LCS approach.
-// Good enough for typical Terraform files.
+// computeEdits produces an edit script with Myers' O(ND) algorithm, using
+// the linear-space divide-and-conquer variant so that large files with few
+// changes diff quickly and in memory proportional to their length. Within
+// each run of changes, deleted lines come before inserted lines.
 func computeEdits(a, b []string) []edit {
-	n := len(a)
-	m := len(b)
-
-	// Build LCS table.
-	lcs := make([][]int, n+1)
-	for i := range lcs {
-		lcs[i] = make([]int, m+1)
-	}
-	for i := n - 1; i >= 0; i-- {
-		for j := m - 1; j >= 0; j-- {
-			if a[i] == b[j] {
-				lcs[i][j] = lcs[i+1][j+1] + 1
-			} else if lcs[i+1][j] >= lcs[i][j+1] {
-				lcs[i][j] = lcs[i+1][j]
-			} else {
-				lcs[i][j] = lcs[i][j+1]
+	// Compare lines by id rather than by content.
+	ids := make(map[string]int, len(a)+len(b))
+	lineIDs := func(lines []string) []int {
+		out := make([]int, len(lines))
+		for i, line := range lines {
+			id, ok := ids[line]
+			if !ok {
+				id = len(ids)
+				ids[line] = id
+			}
+			out[i] = id
+		}
+		return out
+	}
+
+	d := &myersDiff{a: lineIDs(a), b: lineIDs(b)}
+	d.compare(0, len(a), 0, len(b))
+	return groupChanges(d.slideChanges())
+}
+
+// myersDiff holds the state of one computeEdits call.
+type myersDiff struct {
+	a, b  []int
+	edits []edit
+}
+
+// compare appends the edits that turn a[aLo:aHi] into b[bLo:bHi].
+func (d *myersDiff) compare(aLo, aHi, bLo, bHi int) {
+	// Common prefix and suffix.
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.edits = append(d.edits, edit{kind: editEqual, aLine: aLo, bLine: bLo})
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aHi-suffix > aLo && bHi-suffix > bLo && d.a[aHi-suffix-1] == d.b[bHi-suffix-1] {
+		suffix++
+	}
+	aHi -= suffix
+	bHi -= suffix
+
+	x, y, ok := -1, -1, false
+	if aLo < aHi && bLo < bHi {
+		x, y, ok = d.split(aLo, aHi, bLo, bHi)
+	}
+	if ok {
+		d.compare(aLo, x, bLo, y)
+		d.compare(x, aHi, y, bHi)
+	} else {
+		for i := aLo; i < aHi; i++ {
+			d.edits = append(d.edits, edit{kind: editDelete, aLine: i, bLine: -1})
+		}
+		for j := bLo; j < bHi; j++ {
+			d.edits = append(d.edits, edit{kind: editInsert, aLine: -1, bLine: j})
+		}
+	}
+
+	for i := 0; i < suffix; i++ {
+		d.edits = append(d.edits, edit{kind: editEqual, aLine: aHi + i, bLine: bHi + i})
+	}
+}
+
+// split finds a point on a shortest edit path through a[aLo:aHi] and
+// b[bLo:bHi] by searching forward from the start and backward from the end
+// until the two searches overlap. Both ranges must be non-empty and must
+// not share a first or last line. It reports false when the point would not
+// divide the problem.
+func (d *myersDiff) split(aLo, aHi, bLo, bHi int) (int, int, bool) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n+m+1)/2 + 1
+	offset := maxD + 1
+
+	// forward[k] and backward[k] are the furthest x reached on diagonal k
+	// (x - y = k), counted from the start and from the end respectively;
+	// -1 marks a diagonal that cannot be reached.
+	forward := make([]int, 2*offset+1)
+	backward := make([]int, 2*offset+1)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+
+	// reach extends the furthest path on diagonal k by one edit and then
+	// along equal lines. at(x, y) reports whether the lines match.
+	reach := func(v []int, k, step int, at func(x, y int) bool) int {
+		x := -1
+		if step == 0 {
+			x = 0
+		}
+		if k < step && v[offset+k+1] >= 0 && v[offset+k+1]-k <= m {
+			x = v[offset+k+1] // down: insert a line of b
+		}
+		if k > -step && v[offset+k-1] >= 0 && v[offset+k-1]+1 <= n && v[offset+k-1]+1 > x {
+			x = v[offset+k-1] + 1 // right: delete a line of a
+		}
+		if x < 0 {
+			return -1
+		}
+		for x < n && x-k < m && at(x, x-k) {
+			x++
+		}
+		return x
+	}
+	forwardAt := func(x, y int) bool { return d.a[aLo+x] == d.b[bLo+y] }
+	backwardAt := func(x, y int) bool { return d.a[aHi-1-x] == d.b[bHi-1-y] }
+
+	divides := func(x, y int) (int, int, bool) {
+		if (x == 0 && y == 0) || (x == n && y == m) {
+			return 0, 0, false
+		}
+		return aLo + x, bLo + y, true
+	}
+
+	for step := 0; step < maxD; step++ {
+		for k := -step; k <= step; k += 2 {
+			x := reach(forward, k, step, forwardAt)
+			forward[offset+k] = x
+			if x < 0 || !odd {
+				continue
+			}
+			if kb := delta - k; kb >= -(step-1) && kb <= step-1 && backward[offset+kb] >= 0 && x+backward[offset+kb] >= n {
+				return divides(x, x-k)
+			}
+		}
+		for k := -step; k <= step; k += 2 {
+			x := reach(backward, k, step, backwardAt)
+			backward[offset+k] = x
+			if x < 0 || odd {
+				continue
+			}
+			if kf := delta - k; kf >= -step && kf <= step && forward[offset+kf] >= 0 && x+forward[offset+kf] >= n {
+				return divides(forward[offset+kf], forward[offset+kf]-kf)
 			}
 		}
 	}
+	return 0, 0, false
+}
 
-	// Backtrack to produce edits.
-	var edits []edit
-	i, j := 0, 0
-	for i < n && j < m {
-		if a[i] == b[j] {
-			edits = append(edits, edit{kind: editEqual, aLine: i, bLine: j})
+// slideChanges moves each run of only inserted or only deleted lines down
+// past equal lines that repeat its first line, so that a moved block reads
+// as whole lines (such as its closing brace) being inserted at its new
+// place rather than borrowing the closing brace of its neighbour.
+func (d *myersDiff) slideChanges() []edit {
+	edits := d.edits
+	for i := 0; i < len(edits); {
+		if edits[i].kind == editEqual {
 			i++
+			continue
+		}
+		kind := edits[i].kind
+		j := i
+		for j < len(edits) && edits[j].kind == kind {
 			j++
-		} else if lcs[i+1][j] >= lcs[i][j+1] {
-			edits = append(edits, edit{kind: editDelete, aLine: i, bLine: -1})
+		}
+		if j < len(edits) && edits[j].kind != editEqual {
+			// A mixed run of deletes and inserts stays where it is.
+			for j < len(edits) && edits[j].kind != editEqual {
+				j++
+			}
+			i = j
+			continue
+		}
+
+		for j < len(edits) && edits[j].kind == editEqual && d.sameLine(kind, edits[i], edits[j]) {
+			first, next := edits[i], edits[j]
+			if kind == editInsert {
+				edits[i] = edit{kind: editEqual, aLine: next.aLine, bLine: first.bLine}
+				for k := i + 1; k <= j; k++ {
+					edits[k] = edit{kind: editInsert, aLine: -1, bLine: first.bLine + k - i}
+				}
+			} else {
+				edits[i] = edit{kind: editEqual, aLine: first.aLine, bLine: next.bLine}
+				for k := i + 1; k <= j; k++ {
+					edits[k] = edit{kind: editDelete, aLine: first.aLine + k - i, bLine: -1}
+				}
+			}
 			i++
-		} else {
-			edits = append(edits, edit{kind: editInsert, aLine: -1, bLine: j})
 			j++
 		}
+		i = j
 	}
-	for ; i < n; i++ {
-		edits = append(edits, edit{kind: editDelete, aLine: i, bLine: -1})
-	}
-	for ; j < m; j++ {
-		edits = append(edits, edit{kind: editInsert, aLine: -1, bLine: j})
+	return edits
+}
+
+// sameLine reports whether the first line of a run of kind edits matches
+// the line of the equal edit that follows the run.
+func (d *myersDiff) sameLine(kind editKind, first, next edit) bool {
+	if kind == editInsert {
+		return d.b[first.bLine] == d.b[next.bLine]
 	}
+	return d.a[first.aLine] == d.a[next.aLine]
+}
 
+// groupChanges reorders each run of non-equal edits so that its deletes
+// come before its inserts, as in `diff -u`.
+func groupChanges(edits []edit) []edit {
+	for i := 0; i < len(edits); {
+		if edits[i].kind == editEqual {
+			i++
+			continue
+		}
+		j := i
+		for j < len(edits) && edits[j].kind != editEqual {
+			j++
+		}
+		run := edits[i:j]
+		var inserts []edit
+		k := 0
+		for _, e := range run {
+			if e.kind == editDelete {
+				run[k] = e
+				k++
+			} else {
+				inserts = append(inserts, e)
+			}
+		}
+		copy(run[k:], inserts)
+		i = j
+	}
 	return edits
 }
 
-// groupEdits groups edits into unified-diff hunks with `context` lines of
-// surrounding equal lines.
-func groupEdits(edits []edit, aLines, bLines []string, context int) []string {
-	var hunks []string
+// diffHunk is a range of edits shown as one unified-diff hunk, with the
+// 0-indexed first line and the line count on each side.
+type diffHunk struct {
+	start, end     int // indices into edits
+	aStart, aCount int
+	bStart, bCount int
+}
 
+// hunkRanges groups edits into hunks with `context` lines of surrounding
+// equal lines, merging changes whose context overlaps.
+func hunkRanges(edits []edit, context int) []diffHunk {
 	// Find ranges of non-equal edits.
 	type hunkRange struct{ start, end int } // indices into edits
 	var ranges []hunkRange
@@ -128,11 +312,7 @@ func groupEdits(edits []edit, aLines, bLines []string, context int) []string {
 		ranges = append(ranges, hunkRange{start, i})
 	}
 
-	if len(ranges) == 0 {
-		return nil
-	}
-
-	// Merge nearby ranges and emit hunks.
+	var hunks []diffHunk
 	for ri := 0; ri < len(ranges); {
 		// Determine context-expanded range.
 		hStart := ranges[ri].start - context
@@ -154,47 +334,56 @@ func groupEdits(edits []edit, aLines, bLines []string, context int) []string {
 			ri++
 		}
 
-		// Compute line numbers.
-		aStart := 0
-		bStart := 0
-		if hStart < len(edits) {
-			for k := 0; k < hStart; k++ {
-				if edits[k].kind != editInsert {
-					aStart++
-				}
-				if edits[k].kind != editDelete {
-					bStart++
-				}
+		h := diffHunk{start: hStart, end: hEnd}
+		for k := 0; k < hStart; k++ {
+			if edits[k].kind != editInsert {
+				h.aStart++
+			}
+			if edits[k].kind != editDelete {
+				h.bStart++
+			}
+		}
+		for k := hStart; k < hEnd; k++ {
+			if edits[k].kind != editInsert {
+				h.aCount++
+			}
+			if edits[k].kind != editDelete {
+				h.bCount++
 			}
 		}
+		hunks = append(hunks, h)
+	}
 
-		aCount := 0
-		bCount := 0
+	return hunks
+}
+
+// header returns the "@@ -a,n +b,m @@" line of the hunk.
+func (h diffHunk) header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", h.aStart+1, h.aCount, h.bStart+1, h.bCount)
+}
+
+// groupEdits groups edits into unified-diff hunks with `context` lines of
+// surrounding equal lines.
+func groupEdits(edits []edit, aLines, bLines []string, context int) []string {
+	var hunks []string
+	for _, h := range hunkRanges(edits, context) {
 		var body strings.Builder
-		for k := hStart; k < hEnd; k++ {
+		for k := h.start; k < h.end; k++ {
 			e := edits[k]
 			switch e.kind {
 			case editEqual:
 				body.WriteString(" ")
 				body.WriteString(aLines[e.aLine])
-				ensureNewline(&body)
-				aCount++
-				bCount++
 			case editDelete:
 				body.WriteString("-")
 				body.WriteString(aLines[e.aLine])
-				ensureNewline(&body)
-				aCount++
 			case editInsert:
 				body.WriteString("+")
 				body.WriteString(bLines[e.bLine])
-				ensureNewline(&body)
-				bCount++
 			}
+			ensureNewline(&body)
 		}
-
-		header := fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", aStart+1, aCount, bStart+1, bCount)
-		hunks = append(hunks, header+body.String())
+		hunks = append(hunks, h.header()+body.String())
 	}
 
 	return hunks
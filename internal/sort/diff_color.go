@@ -0,0 +1,370 @@
+package sort
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Modes accepted by the color setting.
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// ANSI escape sequences used by colored diffs.
+const (
+	ansiReset      = "\x1b[0m"
+	ansiBold       = "\x1b[1m"
+	ansiCyan       = "\x1b[36m"
+	ansiRed        = "\x1b[31m"
+	ansiGreen      = "\x1b[32m"
+	ansiDimRed     = "\x1b[2;31m"
+	ansiDimGreen   = "\x1b[2;32m"
+	ansiReverse    = "\x1b[7m"
+	ansiReverseOff = "\x1b[27m"
+)
+
+// minMovedLetters is the number of letters and digits a run of lines needs
+// to be shown as moved by color-moved.
+const minMovedLetters = 20
+
+// validateColor checks the color setting.
+func validateColor(mode string) error {
+	switch mode {
+	case "", colorAuto, colorAlways, colorNever:
+		return nil
+	}
+	return fmt.Errorf("unknown color mode %q (expected %s, %s or %s)", mode, colorAuto, colorAlways, colorNever)
+}
+
+// colorEnabled reports whether diffs are colored. In auto mode color is used
+// only when stdout is a terminal and color is not disabled by the
+// environment.
+func (s *Sorter) colorEnabled() bool {
+	switch s.params.Color {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+	if colorDisabledByEnv() {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorDisabledByEnv reports whether the environment asks for plain output:
+// NO_COLOR set to a non-empty value, or a dumb terminal.
+func colorDisabledByEnv() bool {
+	return os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb"
+}
+
+// renderDiff returns the diff printed by diff mode: the plain unified diff,
+// or its colored form when color is enabled.
+func (s *Sorter) renderDiff(aName, bName, a, b string) string {
+	if !s.colorEnabled() {
+		return unifiedDiff(aName, bName, a, b)
+	}
+	return coloredDiff(aName, bName, a, b, s.params.ColorMoved)
+}
+
+// coloredDiff renders the same hunks as unifiedDiff with ANSI colors.
+// Changed lines that pair up with a similar line on the other side have the
+// changed words highlighted. With moved set, runs of lines that were only
+// moved are dimmed instead of shown as a red and green pair.
+func coloredDiff(aName, bName, a, b string, moved bool) string {
+	if a == b {
+		return ""
+	}
+
+	aLines := splitLines(a)
+	bLines := splitLines(b)
+	edits := computeEdits(aLines, bLines)
+
+	var movedA, movedB map[int]bool
+	if moved {
+		movedA, movedB = movedLines(edits, aLines, bLines)
+	}
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "%s--- %s%s\n", ansiBold, aName, ansiReset)
+	fmt.Fprintf(&buf, "%s+++ %s%s\n", ansiBold, bName, ansiReset)
+
+	for _, h := range hunkRanges(edits, 3) {
+		buf.WriteString(ansiCyan + strings.TrimSuffix(h.header(), "\n") + ansiReset + "\n")
+
+		for k := h.start; k < h.end; {
+			if edits[k].kind == editEqual {
+				writeDiffLine(&buf, "", " ", aLines[edits[k].aLine])
+				k++
+				continue
+			}
+
+			// A run of changes: pair the i-th deleted line with the i-th
+			// inserted line for word highlighting.
+			var deleted, inserted []int
+			for ; k < h.end && edits[k].kind != editEqual; k++ {
+				if edits[k].kind == editDelete {
+					deleted = append(deleted, edits[k].aLine)
+				} else {
+					inserted = append(inserted, edits[k].bLine)
+				}
+			}
+			pairs := pairChangedLines(deleted, inserted, aLines, bLines, movedA, movedB)
+
+			for i, line := range deleted {
+				switch {
+				case movedA[line]:
+					writeDiffLine(&buf, ansiDimRed, "-", aLines[line])
+				case pairs[i] >= 0:
+					oldText, _ := highlightWords(aLines[line], bLines[inserted[pairs[i]]])
+					writeDiffLine(&buf, ansiRed, "-", oldText)
+				default:
+					writeDiffLine(&buf, ansiRed, "-", aLines[line])
+				}
+			}
+			paired := map[int]int{}
+			for i, j := range pairs {
+				if j >= 0 {
+					paired[j] = i
+				}
+			}
+			for j, line := range inserted {
+				if movedB[line] {
+					writeDiffLine(&buf, ansiDimGreen, "+", bLines[line])
+				} else if i, ok := paired[j]; ok {
+					_, newText := highlightWords(aLines[deleted[i]], bLines[line])
+					writeDiffLine(&buf, ansiGreen, "+", newText)
+				} else {
+					writeDiffLine(&buf, ansiGreen, "+", bLines[line])
+				}
+			}
+		}
+	}
+
+	return buf.String()
+}
+
+// writeDiffLine writes one diff line in color, keeping the newline outside
+// the escape sequences. An empty color writes the line as is.
+func writeDiffLine(buf *strings.Builder, color, prefix, line string) {
+	line = strings.TrimSuffix(line, "\n")
+	if color == "" {
+		buf.WriteString(prefix + line + "\n")
+		return
+	}
+	buf.WriteString(color + prefix + line + ansiReset + "\n")
+}
+
+// pairChangedLines pairs each deleted line with the inserted line at the
+// same position in the run when neither was moved and the two lines share
+// at least half of their words. It returns, for each deleted line, the index
+// into inserted it is paired with, or -1.
+func pairChangedLines(deleted, inserted []int, aLines, bLines []string, movedA, movedB map[int]bool) []int {
+	pairs := make([]int, len(deleted))
+	for i := range pairs {
+		pairs[i] = -1
+		if i >= len(inserted) || movedA[deleted[i]] || movedB[inserted[i]] {
+			continue
+		}
+		oldWords := diffWords(aLines[deleted[i]])
+		newWords := diffWords(bLines[inserted[i]])
+		common := len(commonWords(oldWords, newWords))
+		if 2*common >= max(countWords(oldWords), countWords(newWords)) && common > 0 {
+			pairs[i] = i
+		}
+	}
+	return pairs
+}
+
+// highlightWords returns both lines with the words that are not common to
+// the two lines shown in reverse video.
+func highlightWords(oldLine, newLine string) (string, string) {
+	oldWords := diffWords(strings.TrimSuffix(oldLine, "\n"))
+	newWords := diffWords(strings.TrimSuffix(newLine, "\n"))
+	common := commonWords(oldWords, newWords)
+
+	oldKeep := map[int]bool{}
+	newKeep := map[int]bool{}
+	for _, pair := range common {
+		oldKeep[pair[0]] = true
+		newKeep[pair[1]] = true
+	}
+	return markWords(oldWords, oldKeep), markWords(newWords, newKeep)
+}
+
+// markWords joins words, wrapping each run of words that are not kept in
+// reverse video. Whitespace between two changed words joins their run.
+func markWords(words []string, keep map[int]bool) string {
+	var b strings.Builder
+	inChange := false
+	for i, word := range words {
+		changed := !keep[i] && !isSpace(word)
+		if isSpace(word) && inChange {
+			// Extend the highlight over spaces between changed words only.
+			next := i + 1
+			changed = next < len(words) && !keep[next]
+		}
+		if changed && !inChange {
+			b.WriteString(ansiReverse)
+		} else if !changed && inChange {
+			b.WriteString(ansiReverseOff)
+		}
+		inChange = changed
+		b.WriteString(word)
+	}
+	if inChange {
+		b.WriteString(ansiReverseOff)
+	}
+	return b.String()
+}
+
+// diffWords splits a line into words, runs of whitespace and single
+// punctuation characters, so that joining the result gives the line back.
+func diffWords(line string) []string {
+	var words []string
+	runes := []rune(line)
+	for i := 0; i < len(runes); {
+		j := i + 1
+		switch {
+		case isWordRune(runes[i]):
+			for j < len(runes) && isWordRune(runes[j]) {
+				j++
+			}
+		case unicode.IsSpace(runes[i]):
+			for j < len(runes) && unicode.IsSpace(runes[j]) {
+				j++
+			}
+		}
+		words = append(words, string(runes[i:j]))
+		i = j
+	}
+	return words
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isSpace(word string) bool {
+	return strings.TrimSpace(word) == ""
+}
+
+// countWords counts the words that are not whitespace.
+func countWords(words []string) int {
+	n := 0
+	for _, w := range words {
+		if !isSpace(w) {
+			n++
+		}
+	}
+	return n
+}
+
+// commonWords returns the index pairs of the longest common subsequence of
+// the non-whitespace words of a and b.
+func commonWords(a, b []string) [][2]int {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] && !isSpace(a[i]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var pairs [][2]int
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case a[i] == b[j] && !isSpace(a[i]):
+			pairs = append(pairs, [2]int{i, j})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
+
+// movedLines finds runs of deleted lines that reappear, in the same order,
+// as a run of inserted lines elsewhere in the diff. Lines are compared with
+// whitespace collapsed, so a block that moved and was realigned still
+// counts as moved. Runs with fewer than minMovedLetters letters and digits,
+// such as a lone closing brace, are not treated as moves.
+func movedLines(edits []edit, aLines, bLines []string) (map[int]bool, map[int]bool) {
+	var deleted, inserted []int
+	insertedAt := map[string][]int{} // line text to positions in inserted
+	for _, e := range edits {
+		switch e.kind {
+		case editDelete:
+			deleted = append(deleted, e.aLine)
+		case editInsert:
+			key := collapseSpaces(bLines[e.bLine])
+			insertedAt[key] = append(insertedAt[key], len(inserted))
+			inserted = append(inserted, e.bLine)
+		}
+	}
+
+	movedA, movedB := map[int]bool{}, map[int]bool{}
+	for i := 0; i < len(deleted); {
+		// The longest run starting at deleted[i] that matches consecutive
+		// lines on both sides.
+		bestStart, bestLen := -1, 0
+		for _, j := range insertedAt[collapseSpaces(aLines[deleted[i]])] {
+			if movedB[inserted[j]] {
+				continue
+			}
+			n := 0
+			for i+n < len(deleted) && j+n < len(inserted) &&
+				deleted[i+n] == deleted[i]+n && inserted[j+n] == inserted[j]+n &&
+				collapseSpaces(aLines[deleted[i+n]]) == collapseSpaces(bLines[inserted[j+n]]) && !movedB[inserted[j+n]] {
+				n++
+			}
+			if n > bestLen {
+				bestStart, bestLen = j, n
+			}
+		}
+
+		if bestLen == 0 {
+			i++
+			continue
+		}
+		letters := 0
+		for n := 0; n < bestLen; n++ {
+			for _, r := range aLines[deleted[i+n]] {
+				if unicode.IsLetter(r) || unicode.IsDigit(r) {
+					letters++
+				}
+			}
+		}
+		if letters < minMovedLetters {
+			i++
+			continue
+		}
+		for n := 0; n < bestLen; n++ {
+			movedA[deleted[i+n]] = true
+			movedB[inserted[bestStart+n]] = true
+		}
+		i += bestLen
+	}
+	return movedA, movedB
+}
+
+// collapseSpaces returns line with runs of whitespace collapsed to one space
+// and leading and trailing whitespace removed.
+func collapseSpaces(line string) string {
+	return strings.Join(strings.Fields(line), " ")
+}
@@ -0,0 +1,150 @@
+package sort
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+var ansiSequence = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+const movedBlockBefore = `resource "b" "x" {
+  ami = "ami-b"
+  instance_type = "t3.micro"
+}
+
+resource "a" "y" {
+  ami = "ami-a"
+}
+`
+
+const movedBlockAfter = `resource "a" "y" {
+  ami = "ami-a"
+}
+
+resource "b" "x" {
+  ami           = "ami-b"
+  instance_type = "t3.micro"
+}
+`
+
+func TestValidateColor(t *testing.T) {
+	for _, mode := range []string{"", colorAuto, colorAlways, colorNever} {
+		if err := validateColor(mode); err != nil {
+			t.Errorf("validateColor(%q) error: %v", mode, err)
+		}
+	}
+	if err := validateColor("yes"); err == nil || !strings.Contains(err.Error(), "unknown color mode") {
+		t.Errorf("expected unknown color mode error, got: %v", err)
+	}
+}
+
+func TestRenderDiffPlain(t *testing.T) {
+	want := unifiedDiff("main.tf", "main.tf", movedBlockBefore, movedBlockAfter)
+
+	t.Run("never", func(t *testing.T) {
+		s := NewSorter(&Params{Color: colorNever, ColorMoved: true}, afero.NewMemMapFs())
+		if got := s.renderDiff("main.tf", "main.tf", movedBlockBefore, movedBlockAfter); got != want {
+			t.Errorf("renderDiff() =\n%s\nwant:\n%s", got, want)
+		}
+	})
+
+	t.Run("auto with NO_COLOR", func(t *testing.T) {
+		t.Setenv("NO_COLOR", "1")
+		s := NewSorter(&Params{}, afero.NewMemMapFs())
+		if got := s.renderDiff("main.tf", "main.tf", movedBlockBefore, movedBlockAfter); got != want {
+			t.Errorf("renderDiff() =\n%s\nwant:\n%s", got, want)
+		}
+	})
+}
+
+func TestColoredDiff(t *testing.T) {
+	plain := unifiedDiff("main.tf", "main.tf", movedBlockBefore, movedBlockAfter)
+
+	for _, moved := range []bool{false, true} {
+		got := coloredDiff("main.tf", "main.tf", movedBlockBefore, movedBlockAfter, moved)
+		if stripped := ansiSequence.ReplaceAllString(got, ""); stripped != plain {
+			t.Errorf("moved=%v: colored diff without escapes =\n%s\nwant:\n%s", moved, stripped, plain)
+		}
+
+		dimmed := strings.Contains(got, ansiDimRed+`-resource "b" "x" {`) &&
+			strings.Contains(got, ansiDimGreen+`+  ami           = "ami-b"`)
+		if dimmed != moved {
+			t.Errorf("moved=%v: expected dimmed moved block %v, got:\n%q", moved, moved, got)
+		}
+	}
+
+	if got := coloredDiff("a", "b", "x\n", "x\n", true); got != "" {
+		t.Errorf("expected empty diff for identical input, got %q", got)
+	}
+}
+
+func TestHighlightWords(t *testing.T) {
+	oldLine, newLine := highlightWords(`  ami = "ami-123"`+"\n", `  ami = "ami-456"`+"\n")
+	if want := `  ami = "` + ansiReverse + "ami-123" + ansiReverseOff + `"`; oldLine != want {
+		t.Errorf("old line = %q, want %q", oldLine, want)
+	}
+	if want := `  ami = "` + ansiReverse + "ami-456" + ansiReverseOff + `"`; newLine != want {
+		t.Errorf("new line = %q, want %q", newLine, want)
+	}
+}
+
+func TestMovedLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  string
+		wantA map[int]bool
+		wantB map[int]bool
+	}{
+		{
+			name:  "realigned block",
+			a:     movedBlockBefore,
+			b:     movedBlockAfter,
+			wantA: map[int]bool{0: true, 1: true, 2: true, 3: true},
+			wantB: map[int]bool{4: true, 5: true, 6: true, 7: true},
+		},
+		{
+			name:  "short runs are not moves",
+			a:     "}\nx = 1\n",
+			b:     "x = 1\n}\n",
+			wantA: map[int]bool{},
+			wantB: map[int]bool{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aLines, bLines := splitLines(tt.a), splitLines(tt.b)
+			gotA, gotB := movedLines(computeEdits(aLines, bLines), aLines, bLines)
+			if !reflect.DeepEqual(gotA, tt.wantA) || !reflect.DeepEqual(gotB, tt.wantB) {
+				t.Errorf("movedLines() = %v, %v; want %v, %v", gotA, gotB, tt.wantA, tt.wantB)
+			}
+		})
+	}
+}
+
+func TestColorDisabledByEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		noColor string
+		term    string
+		want    bool
+	}{
+		{name: "NO_COLOR set", noColor: "1", term: "xterm", want: true},
+		{name: "NO_COLOR empty", noColor: "", term: "xterm", want: false},
+		{name: "dumb terminal", noColor: "", term: "dumb", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+			t.Setenv("TERM", tt.term)
+			if got := colorDisabledByEnv(); got != tt.want {
+				t.Errorf("colorDisabledByEnv() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package sort
 
 import (
+	"math/rand"
 	"reflect"
 	"strings"
 	"testing"
@@ -120,6 +121,65 @@ func TestComputeEdits(t *testing.T) {
 	})
 }
 
+// lcsLength is the reference O(NM) longest common subsequence length.
+func lcsLength(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := len(a) - 1; i >= 0; i-- {
+		cur := make([]int, len(b)+1)
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				cur[j] = prev[j+1] + 1
+			} else {
+				cur[j] = max(prev[j], cur[j+1])
+			}
+		}
+		prev = cur
+	}
+	return prev[0]
+}
+
+// TestComputeEditsRandom checks on random inputs that the edit script turns
+// a into b and keeps as many lines as the longest common subsequence.
+func TestComputeEditsRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randomLines := func() []string {
+		lines := make([]string, rng.Intn(40))
+		for i := range lines {
+			lines[i] = string(rune('a'+rng.Intn(5))) + "\n"
+		}
+		return lines
+	}
+
+	for iter := 0; iter < 2000; iter++ {
+		a, b := randomLines(), randomLines()
+		edits := computeEdits(a, b)
+
+		var gotA, gotB []string
+		equals := 0
+		for _, e := range edits {
+			switch e.kind {
+			case editEqual:
+				if a[e.aLine] != b[e.bLine] {
+					t.Fatalf("equal edit pairs different lines %q and %q", a[e.aLine], b[e.bLine])
+				}
+				gotA = append(gotA, a[e.aLine])
+				gotB = append(gotB, b[e.bLine])
+				equals++
+			case editDelete:
+				gotA = append(gotA, a[e.aLine])
+			case editInsert:
+				gotB = append(gotB, b[e.bLine])
+			}
+		}
+		if !reflect.DeepEqual(gotA, a) && len(a) > 0 || !reflect.DeepEqual(gotB, b) && len(b) > 0 {
+			t.Fatalf("edit script does not cover the inputs in order:\na=%q\nb=%q\nedits=%v", a, b, edits)
+		}
+		if want := lcsLength(a, b); equals != want {
+			t.Fatalf("edit script keeps %d lines, want %d:\na=%q\nb=%q", equals, want, a, b)
+		}
+	}
+}
+
 func TestGroupEdits(t *testing.T) {
 	t.Run("all equal returns nil", func(t *testing.T) {
 		lines := []string{"a\n", "b\n"}
@@ -0,0 +1,197 @@
+package sort
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	gohcl "github.com/hashicorp/hcl/v2/gohcl"
+	hclparse "github.com/hashicorp/hcl/v2/hclparse"
+	hclsyntax "github.com/hashicorp/hcl/v2/hclsyntax"
+	log "github.com/sirupsen/logrus"
+)
+
+// Modes accepted by the duplicate-blocks setting.
+const (
+	duplicateBlocksError = "error"
+	duplicateBlocksWarn  = "warn"
+	duplicateBlocksOff   = "off"
+)
+
+// addressedBlockTypes are the top-level block types whose type and labels
+// must be unique within a module. count and for_each do not change a
+// block's address, so they are not part of it.
+var addressedBlockTypes = []string{"check", "data", "ephemeral", "module", "output", "provider", "resource", "variable"}
+
+// validateDuplicateBlocks checks the duplicate-blocks setting.
+func validateDuplicateBlocks(mode string) error {
+	switch mode {
+	case "", duplicateBlocksWarn, duplicateBlocksError, duplicateBlocksOff:
+		return nil
+	}
+	return fmt.Errorf("unknown duplicate-blocks mode %q (expected %s, %s or %s)",
+		mode, duplicateBlocksWarn, duplicateBlocksError, duplicateBlocksOff)
+}
+
+// addressedBlockSchema selects the addressed blocks of a Terraform JSON
+// file, whose labels are only known from the schema.
+var addressedBlockSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "check", LabelNames: []string{"name"}},
+		{Type: "data", LabelNames: []string{"type", "name"}},
+		{Type: "ephemeral", LabelNames: []string{"type", "name"}},
+		{Type: "module", LabelNames: []string{"name"}},
+		{Type: "output", LabelNames: []string{"name"}},
+		{Type: "provider", LabelNames: []string{"name"}},
+		{Type: "resource", LabelNames: []string{"type", "name"}},
+		{Type: "variable", LabelNames: []string{"name"}},
+	},
+}
+
+// providerAliasSchema selects the alias argument of a provider block.
+var providerAliasSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "alias"}},
+}
+
+// blockIdentity returns the address that identifies block within a module,
+// or false for block types that may be repeated. Provider configurations are
+// identified by their name and alias.
+func blockIdentity(block *hcl.Block) (string, bool) {
+	if !slices.Contains(addressedBlockTypes, block.Type) || len(block.Labels) == 0 {
+		return "", false
+	}
+	identity := block.Type + " " + strings.Join(block.Labels, " ")
+	if block.Type == "provider" {
+		content, _, _ := block.Body.PartialContent(providerAliasSchema)
+		if alias, ok := content.Attributes["alias"]; ok {
+			identity += "." + stringLiteral(alias.Expr)
+		}
+	}
+	return identity, true
+}
+
+// isOverrideFile reports whether path is a Terraform override file,
+// override.tf or *_override.tf, or their .tf.json forms. Override files
+// redeclare addresses of the module by design.
+func isOverrideFile(path string) bool {
+	name := getFileNameFromPath(path)
+	switch {
+	case strings.HasSuffix(name, ".tf.json"):
+		name = strings.TrimSuffix(name, ".tf.json")
+	case strings.HasSuffix(name, ".tf"):
+		name = strings.TrimSuffix(name, ".tf")
+	default:
+		return false
+	}
+	return name == "override" || strings.HasSuffix(name, "_override")
+}
+
+// stringLiteral returns the value of a constant string expression, or its
+// position when the expression is not a constant so that computed values
+// never compare equal.
+func stringLiteral(expr hcl.Expression) string {
+	var value string
+	if diags := gohcl.DecodeExpression(expr, nil, &value); diags.HasErrors() {
+		return expr.Range().String()
+	}
+	return value
+}
+
+// nativeBlocks returns the top-level blocks of body.
+func nativeBlocks(body *hclsyntax.Body) []*hcl.Block {
+	blocks := make([]*hcl.Block, 0, len(body.Blocks))
+	for _, block := range body.Blocks {
+		blocks = append(blocks, block.AsHCLBlock())
+	}
+	return blocks
+}
+
+// jsonBlocks returns the addressed top-level blocks of Terraform JSON
+// content.
+func jsonBlocks(content []byte, filename string) ([]*hcl.Block, error) {
+	file, diag := hclparse.NewParser().ParseJSON(content, filename)
+	if diag.HasErrors() {
+		return nil, fmt.Errorf("failed to parse JSON: %s", diag.Error())
+	}
+	body, _, diag := file.Body.PartialContent(addressedBlockSchema)
+	if diag.HasErrors() {
+		return nil, fmt.Errorf("failed to decode JSON: %s", diag.Error())
+	}
+	return body.Blocks, nil
+}
+
+// checkDuplicateBlocks reports blocks that share an address, naming both
+// source locations of each conflict. Depending on the
+// duplicate-blocks setting the conflicts are an error, a warning, or
+// ignored.
+func (s *Sorter) checkDuplicateBlocks(blocks []*hcl.Block) error {
+	log.Traceln("Starting checkDuplicateBlocks")
+
+	if s.params.DuplicateBlocks == duplicateBlocksOff {
+		return nil
+	}
+
+	seen := map[string]*hcl.Block{}
+	var conflicts []string
+	for _, block := range blocks {
+		identity, ok := blockIdentity(block)
+		if !ok {
+			continue
+		}
+		first, ok := seen[identity]
+		if !ok {
+			seen[identity] = block
+			continue
+		}
+		conflicts = append(conflicts, fmt.Sprintf("duplicate %s: declared at %s and %s",
+			identity,
+			s.sourceLocation(first.DefRange.Start, first.DefRange.Filename),
+			s.sourceLocation(block.DefRange.Start, block.DefRange.Filename)))
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	if s.params.DuplicateBlocks == duplicateBlocksError {
+		return fmt.Errorf("found duplicate block addresses:\n  %s", strings.Join(conflicts, "\n  "))
+	}
+	for _, conflict := range conflicts {
+		log.Warnln(conflict)
+	}
+	return nil
+}
+
+// checkDuplicateBlocksInFiles parses the .tf and .tf.json files among files
+// and runs checkDuplicateBlocks across all of them. Override files are
+// skipped, since their blocks override the originals. Files that do not
+// parse are skipped here; sorting reports their errors.
+func (s *Sorter) checkDuplicateBlocksInFiles(files []string) error {
+	if s.params.DuplicateBlocks == duplicateBlocksOff {
+		return nil
+	}
+
+	var blocks []*hcl.Block
+	for _, f := range files {
+		switch {
+		case isOverrideFile(f):
+		case isJSONFile(f):
+			content, err := s.afs.ReadFile(f)
+			if err != nil {
+				continue
+			}
+			fileBlocks, err := jsonBlocks(content, f)
+			if err != nil {
+				continue
+			}
+			blocks = append(blocks, fileBlocks...)
+		case !sortsAlone(f):
+			body, err := s.parseHclFile(f)
+			if err != nil {
+				continue
+			}
+			blocks = append(blocks, nativeBlocks(body)...)
+		}
+	}
+	return s.checkDuplicateBlocks(blocks)
+}
@@ -0,0 +1,156 @@
+package sort
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestBlockIdentity(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		want   string
+		wantOK bool
+	}{
+		{name: "resource", input: `resource "aws_s3_bucket" "logs" {}`, want: "resource aws_s3_bucket logs", wantOK: true},
+		{name: "resource with count", input: "resource \"aws_s3_bucket\" \"logs\" {\n  count = 2\n}", want: "resource aws_s3_bucket logs", wantOK: true},
+		{name: "variable", input: `variable "region" {}`, want: "variable region", wantOK: true},
+		{name: "provider without alias", input: `provider "aws" {}`, want: "provider aws", wantOK: true},
+		{name: "provider with alias", input: "provider \"aws\" {\n  alias = \"east\"\n}", want: "provider aws.east", wantOK: true},
+		{name: "locals", input: "locals {\n  a = 1\n}", wantOK: false},
+		{name: "moved", input: "moved {\n  from = a.b\n  to = a.c\n}", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := NewSorter(&Params{}, afero.NewMemMapFs()).parseHclBytes([]byte(tt.input), "main.tf")
+			if err != nil {
+				t.Fatalf("parse error: %v", err)
+			}
+			got, ok := blockIdentity(body.Blocks[0].AsHCLBlock())
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("blockIdentity() = %q, %v; want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDuplicateBlocksAcrossFiles(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/dupes", 0755)
+	_ = afero.WriteFile(memFS, "/dupes/a.tf", []byte("variable \"region\" {}\n\nprovider \"aws\" {\n  alias = \"east\"\n}\n"), 0644)
+	_ = afero.WriteFile(memFS, "/dupes/b.tf", []byte("provider \"aws\" {\n  alias = \"west\"\n}\n\nvariable \"region\" {\n  default = \"us-east-1\"\n}\n"), 0644)
+	files := []string{"/dupes/a.tf", "/dupes/b.tf"}
+
+	for _, groupByType := range []bool{false, true} {
+		name := "per-file"
+		if groupByType {
+			name = "group-by-type"
+		}
+
+		t.Run(name+"/error", func(t *testing.T) {
+			s := NewSorter(&Params{GroupByType: groupByType, DuplicateBlocks: duplicateBlocksError}, memFS)
+			_, err := s.sortFiles(files)
+			if err == nil {
+				t.Fatal("expected duplicate error, got nil")
+			}
+			for _, want := range []string{"variable region", "/dupes/a.tf:1", "/dupes/b.tf:5"} {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected error to contain %q, got: %v", want, err)
+				}
+			}
+			if strings.Contains(err.Error(), "provider") {
+				t.Errorf("providers with different aliases should not conflict: %v", err)
+			}
+		})
+
+		t.Run(name+"/warn", func(t *testing.T) {
+			s := NewSorter(&Params{GroupByType: groupByType}, memFS)
+			if _, err := s.sortFiles(files); err != nil {
+				t.Fatalf("expected warning only, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestDuplicateBlocksSkipOverrideFiles(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/mod", 0755)
+	_ = afero.WriteFile(memFS, "/mod/main.tf", []byte("variable \"region\" {}\n\nresource \"aws_vpc\" \"main\" {\n  cidr_block = \"10.0.0.0/16\"\n}\n"), 0644)
+	_ = afero.WriteFile(memFS, "/mod/override.tf", []byte("variable \"region\" {\n  default = \"us-east-1\"\n}\n"), 0644)
+	_ = afero.WriteFile(memFS, "/mod/network_override.tf", []byte("resource \"aws_vpc\" \"main\" {\n  cidr_block = \"10.1.0.0/16\"\n}\n"), 0644)
+	_ = afero.WriteFile(memFS, "/mod/gen_override.tf.json", []byte(`{"variable": {"region": {}}}`), 0644)
+
+	s := NewSorter(&Params{DuplicateBlocks: duplicateBlocksError}, memFS)
+	files := []string{"/mod/gen_override.tf.json", "/mod/main.tf", "/mod/network_override.tf", "/mod/override.tf"}
+	if err := s.checkDuplicateBlocksInFiles(files); err != nil {
+		t.Errorf("expected override files not to conflict, got: %v", err)
+	}
+}
+
+func TestIsOverrideFile(t *testing.T) {
+	tests := map[string]bool{
+		"override.tf":              true,
+		"/mod/network_override.tf": true,
+		"gen_override.tf.json":     true,
+		"override.tf.json":         true,
+		"main.tf":                  false,
+		"overrides.tf":             false,
+		"my_override.tfvars":       false,
+		"override_test.tftest.hcl": false,
+	}
+	for path, want := range tests {
+		if got := isOverrideFile(path); got != want {
+			t.Errorf("isOverrideFile(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestSortBytesDuplicateBlocks(t *testing.T) {
+	input := "output \"id\" {\n  value = 1\n}\n\noutput \"id\" {\n  value = 2\n}\n"
+
+	if _, err := SortBytes([]byte(input), "outputs.tf", &Params{DuplicateBlocks: duplicateBlocksOff}); err != nil {
+		t.Fatalf("expected no error with duplicate-blocks off, got: %v", err)
+	}
+
+	_, err := SortBytes([]byte(input), "outputs.tf", &Params{DuplicateBlocks: duplicateBlocksError})
+	if err == nil || !strings.Contains(err.Error(), "outputs.tf:1 and outputs.tf:5") {
+		t.Fatalf("expected duplicate error with both locations, got: %v", err)
+	}
+
+	_, err = SortBytes([]byte(input), "outputs.tf", &Params{DuplicateBlocks: "fail"})
+	if err == nil || !strings.Contains(err.Error(), "unknown duplicate-blocks mode") {
+		t.Fatalf("expected validation error, got: %v", err)
+	}
+}
+
+func TestDuplicateBlocksInJSONFiles(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/dupes", 0755)
+	_ = afero.WriteFile(memFS, "/dupes/main.tf", []byte("variable \"region\" {}\n\nprovider \"aws\" {\n  alias = \"east\"\n}\n"), 0644)
+	_ = afero.WriteFile(memFS, "/dupes/gen.tf.json", []byte(`{
+  "provider": {
+    "aws": [{"alias": "west"}]
+  },
+  "variable": {
+    "region": {}
+  }
+}
+`), 0644)
+
+	s := NewSorter(&Params{DuplicateBlocks: duplicateBlocksError}, memFS)
+	err := s.checkDuplicateBlocksInFiles([]string{"/dupes/gen.tf.json", "/dupes/main.tf"})
+	if err == nil {
+		t.Fatal("expected duplicate error, got nil")
+	}
+	for _, want := range []string{"variable region", "/dupes/gen.tf.json:6", "/dupes/main.tf:1"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got: %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "provider") {
+		t.Errorf("providers with different aliases should not conflict: %v", err)
+	}
+}
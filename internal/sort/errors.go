@@ -8,3 +8,7 @@ import "errors"
 //
 //	if errors.Is(err, ErrCheckFailed) { ... }
 var ErrCheckFailed = errors.New("tforganize: one or more files would be changed by sort")
+
+// ErrLintFailed is returned by the lint command when one or more ordering
+// violations are found.
+var ErrLintFailed = errors.New("tforganize: ordering violations")
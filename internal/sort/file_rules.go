@@ -0,0 +1,155 @@
+package sort
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	hclsyntax "github.com/hashicorp/hcl/v2/hclsyntax"
+	log "github.com/sirupsen/logrus"
+)
+
+// FileRule routes matching blocks to a specific output file when
+// --group-by-type is enabled. Every matcher that is set must match for the
+// rule to apply; at least one matcher is required.
+type FileRule struct {
+	// Type matches the block type exactly (e.g. "resource").
+	Type string `yaml:"type"`
+	// Labels are glob patterns matched positionally against the block
+	// labels (e.g. ["aws_iam_*"] matches the resource type label).
+	Labels []string `yaml:"labels"`
+	// LabelRegex is a regular expression matched against the block labels
+	// joined with "." (e.g. "aws_security_group.*" or "^aws_route53_").
+	LabelRegex string `yaml:"label-regex"`
+	// Annotation is a string that must appear in the comment directly above
+	// the block (e.g. "tforganize:file=dns.tf").
+	Annotation string `yaml:"annotation"`
+	// File is the output file name for matching blocks.
+	File string `yaml:"file"`
+}
+
+// compiledFileRule is a FileRule with its regular expression compiled.
+type compiledFileRule struct {
+	FileRule
+	labelRegex *regexp.Regexp
+}
+
+// compileFileRules compiles the file-rules setting. Invalid rules are
+// skipped; validateFileRules reports them to the user.
+func compileFileRules(rules []FileRule) []compiledFileRule {
+	compiled := make([]compiledFileRule, 0, len(rules))
+	for _, rule := range rules {
+		c, err := compileFileRule(rule)
+		if err != nil {
+			continue
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled
+}
+
+// compileFileRule validates a single rule and compiles its regular expression.
+func compileFileRule(rule FileRule) (compiledFileRule, error) {
+	c := compiledFileRule{FileRule: rule}
+
+	if rule.Type == "" && len(rule.Labels) == 0 && rule.LabelRegex == "" && rule.Annotation == "" {
+		return c, fmt.Errorf("rule needs at least one of type, labels, label-regex or annotation")
+	}
+	for _, pattern := range rule.Labels {
+		if !doublestar.ValidatePattern(pattern) {
+			return c, fmt.Errorf("invalid label pattern %q", pattern)
+		}
+	}
+	if rule.LabelRegex != "" {
+		re, err := regexp.Compile(rule.LabelRegex)
+		if err != nil {
+			return c, fmt.Errorf("invalid label-regex %q: %w", rule.LabelRegex, err)
+		}
+		c.labelRegex = re
+	}
+	if err := validateFileGroups(map[string]string{"file": rule.File}); err != nil {
+		return c, err
+	}
+
+	return c, nil
+}
+
+// validateFileRules checks every rule in the file-rules setting.
+func validateFileRules(rules []FileRule) error {
+	for i, rule := range rules {
+		if _, err := compileFileRule(rule); err != nil {
+			return fmt.Errorf("file-rules[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// matches reports whether the rule applies to block. comment returns the
+// leading comment of the block and is only called for annotation rules.
+func (r compiledFileRule) matches(block *hclsyntax.Block, comment func() ([]string, error)) (bool, error) {
+	if r.Type != "" && r.Type != block.Type {
+		return false, nil
+	}
+
+	if len(r.Labels) > len(block.Labels) {
+		return false, nil
+	}
+	for i, pattern := range r.Labels {
+		matched, err := doublestar.Match(pattern, block.Labels[i])
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	if r.labelRegex != nil && !r.labelRegex.MatchString(strings.Join(block.Labels, ".")) {
+		return false, nil
+	}
+
+	if r.Annotation != "" {
+		lines, err := comment()
+		if err != nil {
+			return false, err
+		}
+		if !strings.Contains(strings.Join(lines, "\n"), r.Annotation) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// getOutputFileForBlock returns the group-by-type output file for block.
+// The split or merge layout is consulted first, then the first matching
+// file rule wins; unmatched blocks fall back to the block type mapping (see
+// getFileGroup).
+func (s *Sorter) getOutputFileForBlock(block *hclsyntax.Block) (string, error) {
+	log.WithFields(log.Fields{blockTypeLabel: block.Type, blockLabelsLabel: block.Labels}).Traceln("Starting getOutputFileForBlock")
+
+	if s.layout != nil {
+		if file, ok := s.layout(block); ok {
+			return file, nil
+		}
+	}
+
+	comment := func() ([]string, error) {
+		lines, err := s.getLinesFromFile(block.TypeRange.Filename)
+		if err != nil {
+			return nil, fmt.Errorf("could not get lines from file: %w", err)
+		}
+		return s.getNodeComment(lines, block.TypeRange.Start.Line-1, block.TypeRange.Filename), nil
+	}
+
+	for _, rule := range s.fileRules {
+		matched, err := rule.matches(block, comment)
+		if err != nil {
+			return "", err
+		}
+		if matched {
+			log.WithFields(log.Fields{blockTypeLabel: block.Type, blockLabelsLabel: block.Labels, "file": rule.File}).Debugln("Block matched file rule")
+			return rule.File, nil
+		}
+	}
+
+	return getFileGroup(s.params.FileGroups, block.Type), nil
+}
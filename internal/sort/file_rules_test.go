@@ -0,0 +1,110 @@
+package sort
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestValidateFileRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    FileRule
+		wantErr string
+	}{
+		{name: "type rule", rule: FileRule{Type: "provider", File: "providers.tf"}},
+		{name: "label rule", rule: FileRule{Labels: []string{"aws_iam_*"}, File: "iam.tf"}},
+		{name: "no matcher", rule: FileRule{File: "iam.tf"}, wantErr: "at least one of"},
+		{name: "bad regex", rule: FileRule{LabelRegex: "(", File: "iam.tf"}, wantErr: "invalid label-regex"},
+		{name: "bad glob", rule: FileRule{Labels: []string{"[aws"}, File: "iam.tf"}, wantErr: "invalid label pattern"},
+		{name: "bad file", rule: FileRule{Type: "resource", File: "iam.hcl"}, wantErr: ".tf extension"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateFileRules([]FileRule{tt.rule})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+			if !strings.Contains(err.Error(), "file-rules[0]") {
+				t.Errorf("expected error to name the rule index, got: %v", err)
+			}
+		})
+	}
+}
+
+// TestGroupByTypeFileRules verifies that file rules route blocks by label
+// glob, label regex and comment annotation, that the first matching rule
+// wins, and that unmatched blocks fall back to the block type mapping.
+func TestGroupByTypeFileRules(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+
+	const inputPath = "/testrules/main.tf"
+	_ = memFS.MkdirAll("/testrules", 0755)
+	_ = afero.WriteFile(memFS, inputPath, []byte(`resource "aws_iam_role" "app" {
+  name = "app"
+}
+
+resource "aws_security_group" "web" {
+  name = "web"
+}
+
+# tforganize:file=dns.tf
+resource "aws_route53_record" "www" {
+  name = "www"
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
+
+variable "region" {
+  default = "us-east-1"
+}
+`), 0644)
+
+	s := NewSorter(&Params{
+		GroupByType: true,
+		FileRules: []FileRule{
+			{Annotation: "tforganize:file=dns.tf", File: "dns.tf"},
+			{Type: "resource", Labels: []string{"aws_iam_*"}, File: "iam.tf"},
+			{Type: "resource", LabelRegex: `^aws_security_group`, File: "network.tf"},
+			{Type: "resource", Labels: []string{"aws_*"}, File: "catchall.tf"},
+		},
+	}, memFS)
+	results, err := s.sortFile(inputPath)
+	if err != nil {
+		t.Fatalf("sortFile returned unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"dns.tf":       "aws_route53_record",
+		"iam.tf":       "aws_iam_role",
+		"network.tf":   "aws_security_group",
+		"catchall.tf":  "aws_s3_bucket",
+		"variables.tf": "region",
+	}
+	if len(results) != len(want) {
+		t.Errorf("expected %d output files, got %v", len(want), mapKeys(results))
+	}
+	for file, label := range want {
+		content, ok := results[file]
+		if !ok {
+			t.Errorf("expected output key %q not found in results; got keys: %v", file, mapKeys(results))
+			continue
+		}
+		if !strings.Contains(string(content), label) {
+			t.Errorf("%s: expected to contain %q, got:\n%s", file, label, string(content))
+		}
+	}
+	if !strings.Contains(string(results["dns.tf"]), "# tforganize:file=dns.tf") {
+		t.Errorf("dns.tf: expected annotation comment to be kept, got:\n%s", string(results["dns.tf"]))
+	}
+}
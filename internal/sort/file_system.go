@@ -2,10 +2,12 @@ package sort
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/bmatcuk/doublestar/v4"
@@ -217,12 +219,19 @@ func (s *Sorter) combineFiles(inputFilePaths []string) ([]byte, error) {
 	log.WithField("inputFilePaths", inputFilePaths).Traceln("Starting combineFiles")
 
 	var buffer []byte
+	s.combinedSources = nil
 	// Iterate over the input file paths
 	for _, inputPath := range inputFilePaths {
 		inputFileBytes, err := s.afs.ReadFile(inputPath)
 		if err != nil {
 			return nil, fmt.Errorf("could not read file: %w", err)
 		}
+		// Remember where each file starts so that diagnostics can point
+		// back at the input file.
+		s.combinedSources = append(s.combinedSources, combinedSource{
+			path:      inputPath,
+			startLine: bytes.Count(buffer, []byte("\n")) + 1,
+		})
 		buffer = append(buffer, inputFileBytes...)
 	}
 
@@ -279,3 +288,114 @@ func (s *Sorter) writeFile(filename string, fileBytes []byte) error {
 
 	return nil
 }
+
+// filesWithBlocks returns the files that declare at least one block.
+// Group-by-type skips the others, such as comment-only files, so that their
+// comments do not attach to the first block of the next file. Files that
+// are sorted on their own are always kept.
+func (s *Sorter) filesWithBlocks(files []string) ([]string, error) {
+	log.WithField("files", files).Traceln("Starting filesWithBlocks")
+
+	var withBlocks []string
+	for _, f := range files {
+		if sortsAlone(f) {
+			withBlocks = append(withBlocks, f)
+			continue
+		}
+		body, err := s.parseHclFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %s: %w", f, err)
+		}
+		if len(body.Blocks) > 0 {
+			withBlocks = append(withBlocks, f)
+		}
+	}
+	return withBlocks, nil
+}
+
+// emptiedFiles returns the input files with blocks that receive no output
+// file of their own, because all of their blocks moved to other files.
+// Files that are sorted on their own never move and are never emptied.
+func (s *Sorter) emptiedFiles(inputFiles []string, sortedFiles map[string][]byte) ([]string, error) {
+	withBlocks, err := s.filesWithBlocks(inputFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	var emptied []string
+	for _, f := range withBlocks {
+		if sortsAlone(f) {
+			continue
+		}
+		if _, ok := sortedFiles[filepath.Base(f)]; !ok {
+			emptied = append(emptied, f)
+		}
+	}
+	return emptied, nil
+}
+
+// groupsInPlace reports whether group-by-type output replaces the files of
+// the target directory, which deletes the files whose blocks all moved:
+// with inline, or when check or diff preview such a run.
+func (s *Sorter) groupsInPlace(target string) bool {
+	if !s.params.GroupByType || !(s.params.Inline || s.params.Check || s.params.Diff) {
+		return false
+	}
+	info, err := s.getPathInfo(target)
+	return err == nil && info.IsDir()
+}
+
+// removedFiles returns the input files an in-place group-by-type run of
+// target deletes, or nil for any other run.
+func (s *Sorter) removedFiles(target string, inputFiles []string, sortedFiles map[string][]byte) ([]string, error) {
+	if !s.groupsInPlace(target) {
+		return nil, nil
+	}
+	return s.emptiedFiles(inputFiles, sortedFiles)
+}
+
+// checkInPlaceOutputs refuses to replace the files of dir with grouped
+// output when that would touch a file that is not a .tf file or overwrite a
+// file that was not sorted, such as an excluded or comment-only file.
+func (s *Sorter) checkInPlaceOutputs(dir string, inputFiles []string, sortedFiles map[string][]byte) error {
+	withBlocks, err := s.filesWithBlocks(inputFiles)
+	if err != nil {
+		return err
+	}
+	for name := range sortedFiles {
+		path := filepath.Join(dir, name)
+		if filepath.Ext(name) != ".tf" && !sortsAlone(name) {
+			return fmt.Errorf("could not write %s: only Terraform files are written in place", path)
+		}
+		if exists, _ := s.afs.Exists(path); exists && !slices.Contains(withBlocks, path) {
+			return fmt.Errorf("could not write %s: the file exists and was not part of the input", path)
+		}
+	}
+	return nil
+}
+
+// checkRemovable refuses to remove any of the given files that is not a
+// .tf file. Callers run it before writing anything, so that a rejected
+// removal does not leave a directory half-rewritten.
+func checkRemovable(files []string) error {
+	for _, f := range files {
+		if filepath.Ext(f) != ".tf" {
+			return fmt.Errorf("could not remove %s: only .tf files are removed", f)
+		}
+	}
+	return nil
+}
+
+// removeFiles deletes the given .tf files.
+func (s *Sorter) removeFiles(files []string) error {
+	if err := checkRemovable(files); err != nil {
+		return err
+	}
+	for _, f := range files {
+		log.WithField("file", f).Debugln("Removing emptied file...")
+		if err := s.fs.Remove(f); err != nil {
+			return fmt.Errorf("could not remove %s: %w", f, err)
+		}
+	}
+	return nil
+}
@@ -62,10 +62,18 @@ func (s *Sorter) parseHclBytes(content []byte, filename string) (*hclsyntax.Body
 
 // BlockListSorter implements the sort.Interface for []*hclsyntax.Block.
 // When sortByType is true, blocks are ordered by logical type priority
-// (see blockTypePriority); otherwise they are ordered alphabetically by type.
+// (see blockTypePriority, or typeOrder when configured); otherwise they are
+// ordered alphabetically by type.
 type BlockListSorter struct {
 	blocks     []*hclsyntax.Block
 	sortByType bool
+	// typeOrder overrides the built-in type priority when block-order is set.
+	typeOrder blockTypeOrder
+	// comparators selects how blocks of the same type are compared, keyed by
+	// block type. Types without an entry are compared alphabetically by label.
+	comparators map[string]blockComparator
+	// collation selects how labels are compared (byte-wise by default).
+	collation collation
 }
 
 // Len returns the length of the array.
@@ -81,26 +89,51 @@ func (bs BlockListSorter) Less(i, j int) bool {
 	// First, compare the Type fields
 	if block1.Type != block2.Type {
 		if bs.sortByType {
-			return getBlockTypePriority(block1.Type) < getBlockTypePriority(block2.Type)
+			return bs.typeOrder.priority(block1.Type) < bs.typeOrder.priority(block2.Type)
 		}
 		return block1.Type < block2.Type
 	}
 
-	// If the Type is the same, compare the Labels array
-	// based on the order of the strings
-	minLen := len(block1.Labels)
-	if len(block2.Labels) < minLen {
-		minLen = len(block2.Labels)
+	// If the Type is the same, apply the type's comparator
+	comparator := bs.comparators[block1.Type]
+	if comparator.preserve {
+		// sort.Stable keeps equal elements in their original order
+		return false
+	}
+	if comparator.labelIndex > 0 {
+		label1 := labelAt(block1.Labels, comparator.labelIndex)
+		label2 := labelAt(block2.Labels, comparator.labelIndex)
+		if label1 != label2 {
+			return bs.collation.less(label1, label2)
+		}
+	}
+
+	return bs.collation.labelsLess(block1.Labels, block2.Labels)
+}
+
+// labelsLess compares two label lists element by element. When the common
+// labels are equal, the list with fewer labels comes first.
+func (c collation) labelsLess(labels1, labels2 []string) bool {
+	minLen := len(labels1)
+	if len(labels2) < minLen {
+		minLen = len(labels2)
 	}
 
 	for k := 0; k < minLen; k++ {
-		if block1.Labels[k] != block2.Labels[k] {
-			return block1.Labels[k] < block2.Labels[k]
+		if labels1[k] != labels2[k] {
+			return c.less(labels1[k], labels2[k])
 		}
 	}
 
-	// If the common Labels are the same, the one with fewer Labels should come first
-	return len(block1.Labels) < len(block2.Labels)
+	return len(labels1) < len(labels2)
+}
+
+// labelAt returns the label at index i, or "" when the block has fewer labels.
+func labelAt(labels []string, i int) string {
+	if i < len(labels) {
+		return labels[i]
+	}
+	return ""
 }
 
 // Swap swaps two blocks in the array.
@@ -108,9 +141,11 @@ func (bs BlockListSorter) Swap(i, j int) {
 	bs.blocks[i], bs.blocks[j] = bs.blocks[j], bs.blocks[i]
 }
 
-// isSortable returns true if the file is sortable.
+// isSortable returns true if the file is sortable: a Terraform file in the
+// native syntax (.tf) or the JSON syntax (.tf.json), a variable definitions
+// file, or a test or mock data file.
 func isSortable(file fs.FileInfo) bool {
-	if filepath.Ext(file.Name()) != ".tf" {
+	if filepath.Ext(file.Name()) != ".tf" && !sortsAlone(file.Name()) {
 		log.WithField("file.Name()", file.Name()).Debugln("File is not sortable")
 		return false
 	}
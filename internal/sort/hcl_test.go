@@ -3,6 +3,7 @@ package sort
 import (
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
 
@@ -315,6 +316,74 @@ func TestBlockListSorterLess(t *testing.T) {
 	})
 }
 
+func TestBlockListSorterConfigured(t *testing.T) {
+
+	/*********************************************************************/
+	// A custom type order places module before resource.
+	/*********************************************************************/
+
+	t.Run("custom type order", func(t *testing.T) {
+		bs := BlockListSorter{
+			blocks: []*hclsyntax.Block{
+				{Type: "resource"},
+				{Type: "module"},
+			},
+			sortByType: true,
+			typeOrder:  newBlockTypeOrder([]string{"module", "resource"}),
+		}
+		if !bs.Less(1, 0) {
+			t.Error("module should come before resource")
+		}
+		if bs.Less(0, 1) {
+			t.Error("resource should not come before module")
+		}
+	})
+
+	/*********************************************************************/
+	// preserve-original keeps declaration order under sort.Stable.
+	/*********************************************************************/
+
+	t.Run("preserve original", func(t *testing.T) {
+		blocks := []*hclsyntax.Block{
+			{Type: "output", Labels: []string{"zeta"}},
+			{Type: "output", Labels: []string{"alpha"}},
+			{Type: "variable", Labels: []string{"region"}},
+		}
+		sort.Stable(BlockListSorter{
+			blocks:      blocks,
+			sortByType:  true,
+			comparators: map[string]blockComparator{"output": {preserve: true}},
+		})
+		got := []string{blocks[0].Type, blocks[1].Labels[0], blocks[2].Labels[0]}
+		want := []string{"variable", "zeta", "alpha"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	/*********************************************************************/
+	// label:1 compares resource names before resource types.
+	/*********************************************************************/
+
+	t.Run("label index", func(t *testing.T) {
+		bs := BlockListSorter{
+			blocks: []*hclsyntax.Block{
+				{Type: "resource", Labels: []string{"aws_instance", "alpha"}},
+				{Type: "resource", Labels: []string{"aws_eip", "beta"}},
+				{Type: "resource", Labels: []string{"aws_s3_bucket", "alpha"}},
+			},
+			sortByType:  true,
+			comparators: map[string]blockComparator{"resource": {labelIndex: 1}},
+		}
+		if !bs.Less(0, 1) {
+			t.Error("alpha should come before beta regardless of resource type")
+		}
+		if !bs.Less(0, 2) {
+			t.Error("equal names should fall back to the full label list")
+		}
+	})
+}
+
 func TestGetNodeComment(t *testing.T) {
 	s := NewSorter(&Params{}, afero.NewMemMapFs())
 
@@ -0,0 +1,443 @@
+package sort
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	hclparse "github.com/hashicorp/hcl/v2/hclparse"
+	hclsyntax "github.com/hashicorp/hcl/v2/hclsyntax"
+	log "github.com/sirupsen/logrus"
+)
+
+// jsonFileSuffix is the file name suffix of Terraform JSON files.
+const jsonFileSuffix = ".tf.json"
+
+// jsonCommentKey is the property name Terraform JSON uses for comments.
+const jsonCommentKey = "//"
+
+// jsonLabelCounts is the number of labels of each top-level block type in
+// the Terraform JSON syntax. Types that are not listed have no labels.
+var jsonLabelCounts = map[string]int{
+	"check":     1,
+	"data":      2,
+	"ephemeral": 2,
+	"module":    1,
+	"output":    1,
+	"provider":  1,
+	"resource":  2,
+	"variable":  1,
+}
+
+// jsonKind is the kind of a JSON value.
+type jsonKind int
+
+const (
+	jsonLiteral jsonKind = iota
+	jsonObject
+	jsonArray
+)
+
+// jsonValue is a JSON value that keeps the order of object properties,
+// including duplicate property names, which the HCL JSON syntax allows.
+type jsonValue struct {
+	kind    jsonKind
+	members []jsonMember // object properties
+	items   []*jsonValue // array elements
+	literal string       // encoded string, number, boolean or null
+}
+
+// jsonMember is a property of a JSON object.
+type jsonMember struct {
+	key   string
+	value *jsonValue
+}
+
+// jsonBlock is a top-level block of a Terraform JSON file. block only holds
+// the type and labels, so that the block comparators of the native syntax
+// apply unchanged.
+type jsonBlock struct {
+	block *hclsyntax.Block
+	body  *jsonValue
+	// complete is false when the value ended before all of the labels of
+	// the block type were found; its body is then copied as it is.
+	complete bool
+}
+
+// isJSONFile reports whether path is a Terraform JSON file.
+func isJSONFile(path string) bool {
+	return strings.HasSuffix(path, jsonFileSuffix)
+}
+
+// isJSONSyntax reports whether path is a Terraform JSON or a .tfvars.json
+// file.
+func isJSONSyntax(path string) bool {
+	return isJSONFile(path) || (isVarsFile(path) && strings.HasSuffix(path, ".json"))
+}
+
+// sortJSONContent sorts the content of a file in the JSON syntax.
+func (s *Sorter) sortJSONContent(content []byte, filename string) ([]byte, error) {
+	if isVarsFile(filename) {
+		return s.sortVariablesJSON(content, filename)
+	}
+	return s.sortJSONBytes(content, filename)
+}
+
+// parseJSON parses Terraform JSON content into an order-preserving tree.
+func parseJSON(content []byte, filename string) (*jsonValue, error) {
+	log.WithField("filename", filename).Traceln("Starting parseJSON")
+
+	if _, diag := hclparse.NewParser().ParseJSON(content, filename); diag.HasErrors() {
+		return nil, fmt.Errorf("failed to parse JSON: %s", diag.Error())
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	root, err := readJSONValue(dec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("failed to parse JSON: unexpected content after the root object")
+	}
+	if root.kind != jsonObject {
+		return nil, fmt.Errorf("failed to parse JSON: the root value must be an object")
+	}
+	return root, nil
+}
+
+// readJSONValue reads the next value from dec.
+func readJSONValue(dec *json.Decoder) (*jsonValue, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		if t == '[' {
+			value := &jsonValue{kind: jsonArray}
+			for dec.More() {
+				item, err := readJSONValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				value.items = append(value.items, item)
+			}
+			_, err := dec.Token()
+			return value, err
+		}
+		value := &jsonValue{kind: jsonObject}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			item, err := readJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			value.members = append(value.members, jsonMember{key: key.(string), value: item})
+		}
+		_, err := dec.Token()
+		return value, err
+	case string:
+		return &jsonValue{literal: quoteJSON(t)}, nil
+	case json.Number:
+		return &jsonValue{literal: t.String()}, nil
+	case bool:
+		return &jsonValue{literal: strconv.FormatBool(t)}, nil
+	default:
+		return &jsonValue{literal: "null"}, nil
+	}
+}
+
+// quoteJSON encodes s as a JSON string without escaping HTML characters.
+func quoteJSON(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// writeJSONValue writes value to buf indented by two spaces per level.
+func writeJSONValue(buf *bytes.Buffer, value *jsonValue, indent string) {
+	switch value.kind {
+	case jsonObject:
+		if len(value.members) == 0 {
+			buf.WriteString("{}")
+			return
+		}
+		buf.WriteString("{\n")
+		for i, m := range value.members {
+			buf.WriteString(indent + "  " + quoteJSON(m.key) + ": ")
+			writeJSONValue(buf, m.value, indent+"  ")
+			if i < len(value.members)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "}")
+	case jsonArray:
+		if len(value.items) == 0 {
+			buf.WriteString("[]")
+			return
+		}
+		buf.WriteString("[\n")
+		for i, item := range value.items {
+			buf.WriteString(indent + "  ")
+			writeJSONValue(buf, item, indent+"  ")
+			if i < len(value.items)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "]")
+	default:
+		buf.WriteString(value.literal)
+	}
+}
+
+// sortJSONBytes sorts the content of a Terraform JSON file. Top-level blocks
+// are ordered like native blocks and the arguments of their bodies are
+// ordered by meta-arguments and name. Nested objects are copied as they are
+// because the JSON syntax does not tell nested blocks from object values,
+// except for the object keys sorted by sort-object-keys.
+func (s *Sorter) sortJSONBytes(content []byte, filename string) ([]byte, error) {
+	log.WithField("filename", filename).Traceln("Starting sortJSONBytes")
+
+	root, err := parseJSON(content, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, blocks := s.flattenJSONBlocks(root)
+
+	// The bodies scope keeps the top-level block order untouched.
+	if s.params.Scope != scopeBodies {
+		hclBlocks := make(hclsyntax.Blocks, len(blocks))
+		bodies := make(map[*hclsyntax.Block]jsonBlock, len(blocks))
+		for i, b := range blocks {
+			hclBlocks[i] = b.block
+			bodies[b.block] = b
+		}
+		sort.Stable(s.blockListSorter(hclBlocks))
+		for i, block := range hclBlocks {
+			blocks[i] = bodies[block]
+		}
+	}
+
+	sorted := &jsonValue{kind: jsonObject, members: comments}
+	containers := map[*jsonValue]bool{}
+	for _, b := range blocks {
+		if b.complete && s.sortsBodyAt(1) {
+			s.sortJSONBody(b.block, b.body)
+		}
+		insertJSONBlock(sorted, containers, append([]string{b.block.Type}, b.block.Labels...), b.body)
+	}
+
+	var buf bytes.Buffer
+	writeJSONValue(&buf, sorted, "")
+	buf.WriteByte('\n')
+
+	if _, diag := hclparse.NewParser().ParseJSON(buf.Bytes(), filename); diag.HasErrors() {
+		return nil, fmt.Errorf("sorted JSON is invalid: %s", diag.Error())
+	}
+	return buf.Bytes(), nil
+}
+
+// jsonSummaryBody parses Terraform JSON content into a native body of its
+// top-level blocks for the summary and the plan. A block starts at its last
+// label, or at its type when it has none. The properties of a block body
+// become attributes that keep their source ranges; nested objects are not
+// told apart from values, as in sortJSONBytes.
+func jsonSummaryBody(content []byte, filename string) (*hclsyntax.Body, error) {
+	file, diag := hclparse.NewParser().ParseJSON(content, filename)
+	if diag.HasErrors() {
+		return nil, fmt.Errorf("failed to parse JSON: %s", diag.Error())
+	}
+
+	// Duplicate top-level properties are reported, but every name is
+	// returned once, which is all the schema needs.
+	properties, _ := file.Body.JustAttributes()
+	schema := &hcl.BodySchema{}
+	for blockType := range properties {
+		labelNames := make([]string, jsonLabelCounts[blockType])
+		for i := range labelNames {
+			labelNames[i] = fmt.Sprintf("label%d", i)
+		}
+		schema.Blocks = append(schema.Blocks, hcl.BlockHeaderSchema{Type: blockType, LabelNames: labelNames})
+	}
+	// Blocks without all of their labels are left out; sorting copies them
+	// as they are.
+	blocks, _, _ := file.Body.PartialContent(schema)
+
+	body := &hclsyntax.Body{Attributes: hclsyntax.Attributes{}}
+	for _, block := range blocks.Blocks {
+		start := block.TypeRange
+		if len(block.LabelRanges) > 0 {
+			start = block.LabelRanges[len(block.LabelRanges)-1]
+		}
+		attributes := hclsyntax.Attributes{}
+		arguments, _ := block.Body.JustAttributes()
+		for name, argument := range arguments {
+			attributes[name] = &hclsyntax.Attribute{
+				Name:      name,
+				Expr:      &hclsyntax.LiteralValueExpr{SrcRange: argument.Expr.Range()},
+				SrcRange:  argument.Range,
+				NameRange: argument.NameRange,
+			}
+		}
+		body.Blocks = append(body.Blocks, &hclsyntax.Block{
+			Type:      block.Type,
+			Labels:    block.Labels,
+			Body:      &hclsyntax.Body{Attributes: attributes, SrcRange: block.DefRange},
+			TypeRange: start,
+		})
+	}
+	sort.SliceStable(body.Blocks, func(i, j int) bool {
+		return body.Blocks[i].TypeRange.Start.Byte < body.Blocks[j].TypeRange.Start.Byte
+	})
+	return body, nil
+}
+
+// flattenJSONBlocks returns the top-level comments of root and its blocks,
+// with the labels of each block read from the nested objects.
+func (s *Sorter) flattenJSONBlocks(root *jsonValue) ([]jsonMember, []jsonBlock) {
+	var comments []jsonMember
+	var blocks []jsonBlock
+	for _, m := range root.members {
+		if m.key == jsonCommentKey {
+			if !s.params.RemoveComments {
+				comments = append(comments, m)
+			}
+			continue
+		}
+		blocks = s.collectJSONBlocks(blocks, m.key, nil, m.value)
+	}
+	return comments, blocks
+}
+
+// collectJSONBlocks appends the blocks of type blockType found in value,
+// whose enclosing objects supplied labels, to blocks.
+func (s *Sorter) collectJSONBlocks(blocks []jsonBlock, blockType string, labels []string, value *jsonValue) []jsonBlock {
+	if len(labels) == jsonLabelCounts[blockType] || value.kind != jsonObject {
+		return append(blocks, jsonBlock{
+			block:    &hclsyntax.Block{Type: blockType, Labels: labels},
+			body:     value,
+			complete: len(labels) == jsonLabelCounts[blockType],
+		})
+	}
+	for _, m := range value.members {
+		if m.key == jsonCommentKey && s.params.RemoveComments {
+			continue
+		}
+		blocks = s.collectJSONBlocks(blocks, blockType, append(labels[:len(labels):len(labels)], m.key), m.value)
+	}
+	return blocks
+}
+
+// insertJSONBlock adds body to root under the block type and labels in
+// path. Blocks that share a type or leading labels share the enclosing
+// objects, which are created in the order the blocks are inserted.
+func insertJSONBlock(root *jsonValue, containers map[*jsonValue]bool, path []string, body *jsonValue) {
+	node := root
+	for _, key := range path[:len(path)-1] {
+		var next *jsonValue
+		for i := len(node.members) - 1; i >= 0; i-- {
+			if node.members[i].key == key && containers[node.members[i].value] {
+				next = node.members[i].value
+				break
+			}
+		}
+		if next == nil {
+			next = &jsonValue{kind: jsonObject}
+			containers[next] = true
+			node.members = append(node.members, jsonMember{key: key, value: next})
+		}
+		node = next
+	}
+	node.members = append(node.members, jsonMember{key: path[len(path)-1], value: body})
+}
+
+// sortJSONBody orders the arguments of a block body like getSortedBlockKeys
+// orders native attributes: comments, pre-meta arguments, arguments and
+// post-meta arguments. A body given as an array holds one block per element.
+func (s *Sorter) sortJSONBody(block *hclsyntax.Block, body *jsonValue) {
+	log.WithFields(log.Fields{blockTypeLabel: block.Type, blockLabelsLabel: block.Labels}).Traceln("Starting sortJSONBody")
+
+	if body.kind == jsonArray {
+		for _, item := range body.items {
+			s.sortJSONBody(block, item)
+		}
+		return
+	}
+	if body.kind != jsonObject {
+		return
+	}
+
+	var comments []jsonMember
+	byKey := map[string][]jsonMember{}
+	keys := map[int][]string{}
+	metaArgs := lookupMetaArguments(s.metaArguments, block)
+	for _, m := range body.members {
+		if m.key == jsonCommentKey {
+			if !s.params.RemoveComments {
+				comments = append(comments, m)
+			}
+			continue
+		}
+		if _, ok := byKey[m.key]; !ok {
+			switch {
+			case stringExists(metaArgs[0], m.key):
+				keys[0] = append(keys[0], m.key)
+			case stringExists(metaArgs[1], m.key):
+				keys[2] = append(keys[2], m.key)
+			default:
+				keys[1] = append(keys[1], m.key)
+			}
+		}
+		if s.params.SortObjectKeys && stringExists(s.objectKeyArguments(), m.key) {
+			s.sortJSONObjectKeys(m.value)
+		}
+		byKey[m.key] = append(byKey[m.key], m)
+	}
+
+	sortKeysByMetaArgs(keys[0], metaArgs[0])
+	s.sortKeys(keys[1])
+	if profile, ok := s.getArgumentProfile(block); ok {
+		applyArgumentProfile(keys[1], profile, s.keyName)
+	}
+	if override, ok := s.params.MetaArguments[block.Type]; ok && override.Post != nil {
+		sortKeysByMetaArgs(keys[2], metaArgs[1])
+	} else {
+		s.sortKeys(keys[2])
+	}
+
+	body.members = comments
+	for i := 0; i < 3; i++ {
+		for _, key := range keys[i] {
+			body.members = append(body.members, byKey[key]...)
+		}
+	}
+}
+
+// sortJSONObjectKeys sorts the keys of every object in value with the
+// configured collation.
+func (s *Sorter) sortJSONObjectKeys(value *jsonValue) {
+	for _, item := range value.items {
+		s.sortJSONObjectKeys(item)
+	}
+	for _, m := range value.members {
+		s.sortJSONObjectKeys(m.value)
+	}
+	sort.SliceStable(value.members, func(i, j int) bool {
+		return s.collation.less(value.members[i].key, value.members[j].key)
+	})
+}
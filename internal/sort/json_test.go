@@ -0,0 +1,228 @@
+package sort
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+// unsortedJSON is a Terraform JSON file with blocks, labels and arguments
+// out of order.
+const unsortedJSON = `{
+  "output": {"b": {"value": "x"}, "a": {"value": "<y>", "description": "d"}},
+  "resource": {
+    "aws_instance": {
+      "web": {"tags": {"b": "1", "a": "2"}, "ami": "abc", "count": 2, "depends_on": ["aws_vpc.main"]},
+      "app": {"ami": "x", "//": "note"}
+    }
+  },
+  "//": "top comment",
+  "provider": {"aws": [{"region": "us-east-1"}, {"region": "us-west-2", "alias": "west"}]}
+}
+`
+
+// sortedJSON is unsortedJSON sorted with the default settings.
+const sortedJSON = `{
+  "//": "top comment",
+  "resource": {
+    "aws_instance": {
+      "app": {
+        "//": "note",
+        "ami": "x"
+      },
+      "web": {
+        "count": 2,
+        "ami": "abc",
+        "tags": {
+          "b": "1",
+          "a": "2"
+        },
+        "depends_on": [
+          "aws_vpc.main"
+        ]
+      }
+    }
+  },
+  "provider": {
+    "aws": [
+      {
+        "region": "us-east-1"
+      },
+      {
+        "alias": "west",
+        "region": "us-west-2"
+      }
+    ]
+  },
+  "output": {
+    "a": {
+      "description": "d",
+      "value": "<y>"
+    },
+    "b": {
+      "value": "x"
+    }
+  }
+}
+`
+
+func TestSortJSONBytes(t *testing.T) {
+	tests := []struct {
+		name   string
+		params *Params
+		input  string
+		want   string
+	}{
+		{
+			name:   "default",
+			params: &Params{},
+			input:  unsortedJSON,
+			want:   sortedJSON,
+		},
+		{
+			name:   "already sorted",
+			params: &Params{},
+			input:  sortedJSON,
+			want:   sortedJSON,
+		},
+		{
+			name:   "remove comments and sort object keys",
+			params: &Params{RemoveComments: true, SortObjectKeys: true},
+			input:  `{"//": "c", "resource": {"aws_s3_bucket": {"b": {"//": "c", "tags": {"z": "1", "a": "2"}}}}}`,
+			want: `{
+  "resource": {
+    "aws_s3_bucket": {
+      "b": {
+        "tags": {
+          "a": "2",
+          "z": "1"
+        }
+      }
+    }
+  }
+}
+`,
+		},
+		{
+			name:   "blocks scope keeps bodies",
+			params: &Params{Scope: scopeBlocks},
+			input:  `{"variable": {"b": {"default": 1, "type": "number"}, "a": {}}}`,
+			want: `{
+  "variable": {
+    "a": {},
+    "b": {
+      "default": 1,
+      "type": "number"
+    }
+  }
+}
+`,
+		},
+		{
+			name:   "preserve-original comparator",
+			params: &Params{BlockComparators: map[string]string{"output": comparatorPreserveOriginal}},
+			input:  `{"output": {"b": {"value": 1}, "a": {"value": 2}}, "locals": {"x": 1}}`,
+			want: `{
+  "locals": {
+    "x": 1
+  },
+  "output": {
+    "b": {
+      "value": 1
+    },
+    "a": {
+      "value": 2
+    }
+  }
+}
+`,
+		},
+		{
+			name:   "label comparator regroups blocks",
+			params: &Params{BlockComparators: map[string]string{"resource": "label:1"}},
+			input:  `{"resource": {"b": {"y": {}}, "a": {"z": {}, "x": {}}}}`,
+			want: `{
+  "resource": {
+    "a": {
+      "x": {},
+      "z": {}
+    },
+    "b": {
+      "y": {}
+    }
+  }
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSorter(tt.params, afero.NewMemMapFs())
+			if err := s.validateRules(); err != nil {
+				t.Fatalf("validateRules() error = %v", err)
+			}
+			got, err := s.sortJSONBytes([]byte(tt.input), "main.tf.json")
+			if err != nil {
+				t.Fatalf("sortJSONBytes() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("sortJSONBytes() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortJSONBytesInvalid(t *testing.T) {
+	tests := []string{`{"resource": `, `[]`, `{} {}`}
+	for _, input := range tests {
+		s := NewSorter(&Params{}, afero.NewMemMapFs())
+		if _, err := s.sortJSONBytes([]byte(input), "main.tf.json"); err == nil {
+			t.Errorf("sortJSONBytes(%q) expected an error", input)
+		}
+	}
+}
+
+func TestSortJSONFiles(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/json", 0755)
+	_ = afero.WriteFile(memFS, "/json/main.tf.json", []byte(unsortedJSON), 0644)
+	_ = afero.WriteFile(memFS, "/json/variables.tf", []byte("variable \"region\" {}\n"), 0644)
+
+	t.Run("check reports the JSON file", func(t *testing.T) {
+		s := NewSorter(&Params{Check: true}, memFS)
+		err := s.run("/json")
+		if !errors.Is(err, ErrCheckFailed) {
+			t.Fatalf("run() error = %v, want ErrCheckFailed", err)
+		}
+	})
+
+	t.Run("group-by-type keeps the JSON file name", func(t *testing.T) {
+		s := NewSorter(&Params{GroupByType: true, OutputDir: "/out"}, memFS)
+		if err := s.run("/json"); err != nil {
+			t.Fatalf("run() error = %v", err)
+		}
+		got, err := afero.ReadFile(memFS, "/out/main.tf.json")
+		if err != nil {
+			t.Fatalf("could not read output: %v", err)
+		}
+		if string(got) != sortedJSON {
+			t.Errorf("main.tf.json =\n%s\nwant:\n%s", got, sortedJSON)
+		}
+		if exists, _ := afero.Exists(memFS, "/out/variables.tf"); !exists {
+			t.Error("expected variables.tf in the output directory")
+		}
+	})
+
+	t.Run("lint reports the JSON file", func(t *testing.T) {
+		s := NewSorter(&Params{}, memFS)
+		violations, err := s.lint("/json")
+		if err != nil {
+			t.Fatalf("lint() error = %v", err)
+		}
+		if len(violations) != 1 || violations[0].Filename != "/json/main.tf.json" {
+			t.Errorf("lint() = %v, want one violation for main.tf.json", violations)
+		}
+	})
+}
@@ -0,0 +1,183 @@
+package sort
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	gosort "sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	hclsyntax "github.com/hashicorp/hcl/v2/hclsyntax"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// Strategies accepted by the split command.
+const (
+	splitByType         = "by-type"
+	splitByResourceType = "by-resource-type"
+	splitByPrefix       = "by-prefix"
+)
+
+var splitStrategies = []string{splitByType, splitByResourceType, splitByPrefix}
+
+// LayoutResult describes the files written and removed by Split and Merge.
+type LayoutResult struct {
+	// Written lists the files written, in name order.
+	Written []string
+	// Removed lists the input files that were deleted because all of their
+	// blocks moved to other files, in name order.
+	Removed []string
+}
+
+// layoutRouter picks the output file of a block for split and merge. It
+// returns false to leave the block to the group-by-type routing.
+type layoutRouter func(block *hclsyntax.Block) (string, bool)
+
+// validateSplitStrategy checks a split strategy.
+func validateSplitStrategy(strategy string) error {
+	if slices.Contains(splitStrategies, strategy) {
+		return nil
+	}
+	return fmt.Errorf("unknown split strategy %q (expected one of %s)", strategy, strings.Join(splitStrategies, ", "))
+}
+
+// splitRouter returns the router for a split strategy. by-type uses the
+// group-by-type routing unchanged. by-resource-type sends resource and data
+// blocks to a file named after their type, and by-prefix sends resource,
+// data and module blocks to a file named after the part of their name
+// before the first underscore or hyphen. Other blocks are routed by type.
+func splitRouter(strategy string) layoutRouter {
+	switch strategy {
+	case splitByResourceType:
+		return func(block *hclsyntax.Block) (string, bool) {
+			if (block.Type != "resource" && block.Type != "data") || len(block.Labels) == 0 {
+				return "", false
+			}
+			return block.Labels[0] + ".tf", true
+		}
+	case splitByPrefix:
+		return func(block *hclsyntax.Block) (string, bool) {
+			if (block.Type != "resource" && block.Type != "data" && block.Type != "module") || len(block.Labels) == 0 {
+				return "", false
+			}
+			name := block.Labels[len(block.Labels)-1]
+			prefix, _, found := strings.Cut(strings.ReplaceAll(name, "-", "_"), "_")
+			if !found || !hclsyntax.ValidIdentifier(prefix) {
+				return "", false
+			}
+			return name[:len(prefix)] + ".tf", true
+		}
+	}
+	return nil
+}
+
+// Split restructures the Terraform module in dir into one file per group
+// chosen by strategy: "by-type", "by-resource-type" or "by-prefix". Blocks
+// keep their comments, files left without blocks are deleted, and every
+// written file is sorted with settings.
+func Split(dir, strategy string, settings *Params) (*LayoutResult, error) {
+	s := NewSorter(settings, afero.NewOsFs())
+	return s.split(dir, strategy)
+}
+
+// Merge collapses the Terraform module in dir into the single file named
+// file, sorted with settings. The other files are deleted.
+func Merge(dir, file string, settings *Params) (*LayoutResult, error) {
+	s := NewSorter(settings, afero.NewOsFs())
+	return s.merge(dir, file)
+}
+
+// split is the internal entry point for Split.
+func (s *Sorter) split(dir, strategy string) (*LayoutResult, error) {
+	log.WithFields(log.Fields{"dir": dir, "strategy": strategy}).Traceln("Starting split")
+
+	if err := validateSplitStrategy(strategy); err != nil {
+		return nil, err
+	}
+	return s.relayout(dir, splitRouter(strategy))
+}
+
+// merge is the internal entry point for Merge.
+func (s *Sorter) merge(dir, file string) (*LayoutResult, error) {
+	log.WithFields(log.Fields{"dir": dir, "file": file}).Traceln("Starting merge")
+
+	if file == "" || filepath.Base(file) != file || !strings.HasSuffix(file, ".tf") {
+		return nil, fmt.Errorf("invalid merge file %q: expected a .tf file name without a directory", file)
+	}
+	return s.relayout(dir, func(*hclsyntax.Block) (string, bool) { return file, true })
+}
+
+// relayout sorts the files in dir into the files chosen by route, writes
+// them to the output directory (dir itself by default) and, when writing in
+// place, deletes the input files whose blocks all moved elsewhere.
+func (s *Sorter) relayout(dir string, route layoutRouter) (*LayoutResult, error) {
+	if s.params.Recursive {
+		return nil, fmt.Errorf("the recursive flag is not supported when splitting or merging files")
+	}
+	if s.params.KeepHeader && (!s.params.HasHeader || s.params.HeaderPattern == "") {
+		return nil, fmt.Errorf("keep-header requires has-header=true and a non-empty header-pattern")
+	}
+	for _, p := range s.params.Excludes {
+		if !doublestar.ValidatePattern(p) {
+			return nil, fmt.Errorf("invalid exclude pattern %q", p)
+		}
+	}
+	if err := s.validateRules(); err != nil {
+		return nil, err
+	}
+
+	files, err := s.getFilesInFolder(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not get files in %s: %w", dir, err)
+	}
+
+	sorter := NewSorter(s.params, s.fs)
+	sorter.params.GroupByType = true
+	sorter.layout = route
+	sortedFiles, err := sorter.sortFiles(files)
+	if err != nil {
+		return nil, err
+	}
+	if len(sortedFiles) == 0 {
+		return nil, fmt.Errorf("no Terraform blocks found in %s", dir)
+	}
+
+	outputDir := s.params.OutputDir
+	if outputDir == "" {
+		outputDir = dir
+	}
+
+	var emptied []string
+	if filepath.Clean(outputDir) == filepath.Clean(dir) {
+		if err := s.checkInPlaceOutputs(dir, files, sortedFiles); err != nil {
+			return nil, err
+		}
+		if emptied, err = s.emptiedFiles(files, sortedFiles); err != nil {
+			return nil, err
+		}
+		if err := checkRemovable(emptied); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.afs.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("could not create the output directory: %w", err)
+	}
+	result := &LayoutResult{}
+	for name, content := range sortedFiles {
+		path := filepath.Join(outputDir, name)
+		if err := s.writeFile(path, content); err != nil {
+			return nil, fmt.Errorf("could not write %s: %w", path, err)
+		}
+		result.Written = append(result.Written, path)
+	}
+	if err := s.removeFiles(emptied); err != nil {
+		return nil, err
+	}
+	result.Removed = emptied
+	gosort.Strings(result.Written)
+
+	return result, nil
+}
@@ -0,0 +1,197 @@
+package sort
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+// layoutMain has a commented resource and blocks sharing a name prefix.
+const layoutMain = `# Network VPC.
+resource "aws_vpc" "network_main" {
+  cidr_block = "10.0.0.0/16"
+}
+
+variable "region" {
+  type = string
+}
+
+resource "aws_subnet" "network_a" {
+  vpc_id = aws_vpc.network_main.id
+}
+
+resource "aws_vpc" "shared" {
+  cidr_block = "10.1.0.0/16"
+}
+`
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		strategy    string
+		wantWritten []string
+		wantRemoved []string
+	}{
+		{
+			strategy:    splitByType,
+			wantWritten: []string{"/lay/main.tf", "/lay/variables.tf", "/lay/versions.tf"},
+		},
+		{
+			strategy:    splitByResourceType,
+			wantWritten: []string{"/lay/aws_subnet.tf", "/lay/aws_vpc.tf", "/lay/variables.tf", "/lay/versions.tf"},
+			wantRemoved: []string{"/lay/main.tf"},
+		},
+		{
+			strategy:    splitByPrefix,
+			wantWritten: []string{"/lay/main.tf", "/lay/network.tf", "/lay/variables.tf", "/lay/versions.tf"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			memFS := afero.NewMemMapFs()
+			_ = memFS.MkdirAll("/lay", 0755)
+			_ = afero.WriteFile(memFS, "/lay/main.tf", []byte(layoutMain), 0644)
+			_ = afero.WriteFile(memFS, "/lay/versions.tf", []byte("terraform {\n  required_version = \">= 1.5\"\n}\n"), 0644)
+			_ = afero.WriteFile(memFS, "/lay/notes.tf", []byte("# Nothing here yet.\n"), 0644)
+			result, err := NewSorter(&Params{}, memFS).split("/lay", tt.strategy)
+			if err != nil {
+				t.Fatalf("split() error: %v", err)
+			}
+			if !reflect.DeepEqual(result.Written, tt.wantWritten) || !reflect.DeepEqual(result.Removed, tt.wantRemoved) {
+				t.Errorf("split() = %+v, want written %v, removed %v", result, tt.wantWritten, tt.wantRemoved)
+			}
+			for _, f := range tt.wantRemoved {
+				if exists, _ := afero.Exists(memFS, f); exists {
+					t.Errorf("expected %s to be removed", f)
+				}
+			}
+			if exists, _ := afero.Exists(memFS, "/lay/notes.tf"); !exists {
+				t.Error("expected the comment-only file to be kept")
+			}
+		})
+	}
+}
+
+func TestSplitByPrefixKeepsComments(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/lay", 0755)
+	_ = afero.WriteFile(memFS, "/lay/main.tf", []byte(layoutMain), 0644)
+	_ = afero.WriteFile(memFS, "/lay/versions.tf", []byte("terraform {\n  required_version = \">= 1.5\"\n}\n"), 0644)
+	_ = afero.WriteFile(memFS, "/lay/notes.tf", []byte("# Nothing here yet.\n"), 0644)
+	if _, err := NewSorter(&Params{}, memFS).split("/lay", splitByPrefix); err != nil {
+		t.Fatalf("split() error: %v", err)
+	}
+
+	network, _ := afero.ReadFile(memFS, "/lay/network.tf")
+	want := `resource "aws_subnet" "network_a" {
+  vpc_id = aws_vpc.network_main.id
+}
+
+# Network VPC.
+resource "aws_vpc" "network_main" {
+  cidr_block = "10.0.0.0/16"
+}
+`
+	if string(network) != want {
+		t.Errorf("network.tf =\n%s\nwant:\n%s", network, want)
+	}
+	main, _ := afero.ReadFile(memFS, "/lay/main.tf")
+	if want := "resource \"aws_vpc\" \"shared\" {\n  cidr_block = \"10.1.0.0/16\"\n}\n"; string(main) != want {
+		t.Errorf("main.tf =\n%s\nwant:\n%s", main, want)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/lay", 0755)
+	_ = afero.WriteFile(memFS, "/lay/main.tf", []byte(layoutMain), 0644)
+	_ = afero.WriteFile(memFS, "/lay/versions.tf", []byte("terraform {\n  required_version = \">= 1.5\"\n}\n"), 0644)
+	_ = afero.WriteFile(memFS, "/lay/notes.tf", []byte("# Nothing here yet.\n"), 0644)
+	result, err := NewSorter(&Params{}, memFS).merge("/lay", "example.tf")
+	if err != nil {
+		t.Fatalf("merge() error: %v", err)
+	}
+	if want := []string{"/lay/main.tf", "/lay/versions.tf"}; !reflect.DeepEqual(result.Removed, want) {
+		t.Errorf("removed = %v, want %v", result.Removed, want)
+	}
+
+	merged, _ := afero.ReadFile(memFS, "/lay/example.tf")
+	if !strings.HasPrefix(string(merged), "terraform {") || !strings.Contains(string(merged), "# Network VPC.\nresource \"aws_vpc\" \"network_main\"") {
+		t.Errorf("unexpected merged file:\n%s", merged)
+	}
+}
+
+func TestMergeOutputDir(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/lay", 0755)
+	_ = afero.WriteFile(memFS, "/lay/main.tf", []byte(layoutMain), 0644)
+	_ = afero.WriteFile(memFS, "/lay/versions.tf", []byte("terraform {\n  required_version = \">= 1.5\"\n}\n"), 0644)
+	_ = afero.WriteFile(memFS, "/lay/notes.tf", []byte("# Nothing here yet.\n"), 0644)
+	result, err := NewSorter(&Params{OutputDir: "/out"}, memFS).merge("/lay", "main.tf")
+	if err != nil {
+		t.Fatalf("merge() error: %v", err)
+	}
+	if !reflect.DeepEqual(result.Written, []string{"/out/main.tf"}) || len(result.Removed) != 0 {
+		t.Errorf("merge() = %+v, want only /out/main.tf written", result)
+	}
+	if exists, _ := afero.Exists(memFS, "/lay/versions.tf"); !exists {
+		t.Error("expected the input files to be left alone with output-dir")
+	}
+}
+
+func TestLayoutErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(s *Sorter) error
+		want string
+	}{
+		{
+			name: "unknown strategy",
+			run:  func(s *Sorter) error { _, err := s.split("/lay", "by-color"); return err },
+			want: `unknown split strategy "by-color"`,
+		},
+		{
+			name: "merge file with directory",
+			run:  func(s *Sorter) error { _, err := s.merge("/lay", "sub/main.tf"); return err },
+			want: `invalid merge file "sub/main.tf"`,
+		},
+		{
+			name: "overwrites excluded file",
+			run: func(s *Sorter) error {
+				s.params.Excludes = []string{"variables.tf"}
+				_ = afero.WriteFile(s.fs, "/lay/variables.tf", []byte("# generated\n"), 0644)
+				_, err := s.split("/lay", splitByType)
+				return err
+			},
+			want: "could not write /lay/variables.tf: the file exists and was not part of the input",
+		},
+		{
+			name: "recursive",
+			run: func(s *Sorter) error {
+				s.params.Recursive = true
+				_, err := s.merge("/lay", "main.tf")
+				return err
+			},
+			want: "the recursive flag is not supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memFS := afero.NewMemMapFs()
+			_ = memFS.MkdirAll("/lay", 0755)
+			_ = afero.WriteFile(memFS, "/lay/main.tf", []byte(layoutMain), 0644)
+			_ = afero.WriteFile(memFS, "/lay/versions.tf", []byte("terraform {\n  required_version = \">= 1.5\"\n}\n"), 0644)
+			_ = afero.WriteFile(memFS, "/lay/notes.tf", []byte("# Nothing here yet.\n"), 0644)
+			err := tt.run(NewSorter(&Params{}, memFS))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got: %v", tt.want, err)
+			}
+			if exists, _ := afero.Exists(memFS, "/lay/main.tf"); !exists {
+				t.Error("expected files to be left untouched on error")
+			}
+		})
+	}
+}
@@ -0,0 +1,364 @@
+package sort
+
+import (
+	"fmt"
+	"os"
+	gosort "sort"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	hclsyntax "github.com/hashicorp/hcl/v2/hclsyntax"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// Violation is a single ordering problem reported by Lint.
+type Violation struct {
+	Filename string
+	Line     int
+	Column   int
+	Message  string
+}
+
+// String formats the violation as "file:line:column: message".
+func (v Violation) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", v.Filename, v.Line, v.Column, v.Message)
+}
+
+// Lint reports every place where a Terraform file or folder deviates from
+// the order Sort would produce, using the same rules. It does not modify
+// any file.
+func Lint(target string, settings *Params) ([]Violation, error) {
+	s := NewSorter(settings, afero.NewOsFs())
+	return s.lint(target)
+}
+
+// lint is the internal entry point for a lint execution.
+func (s *Sorter) lint(target string) ([]Violation, error) {
+	if err := s.validateRules(); err != nil {
+		return nil, err
+	}
+
+	if !s.params.Recursive {
+		files, err := s.getFilesFromTarget(target)
+		if err != nil {
+			return nil, fmt.Errorf("could not get files from target: %w", err)
+		}
+		return s.lintFiles(files)
+	}
+
+	info, err := s.getPathInfo(target)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("the recursive flag requires a directory target")
+	}
+
+	var violations []Violation
+	err = afero.Walk(s.fs, target, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		files, err := s.getFilesInFolder(path)
+		if err != nil {
+			return fmt.Errorf("could not get files in %s: %w", path, err)
+		}
+		if len(files) == 0 {
+			return nil
+		}
+
+		// Each directory is a separate module, as in runRecursive.
+		dirParams := *s.params
+		dirParams.Recursive = false
+		dirViolations, err := NewSorter(&dirParams, s.fs).lintFiles(files)
+		if err != nil {
+			return fmt.Errorf("could not lint files in %s: %w", path, err)
+		}
+		violations = append(violations, dirViolations...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return violations, nil
+}
+
+// lintFiles lints the files of a single module.
+func (s *Sorter) lintFiles(files []string) ([]Violation, error) {
+	log.WithField("files", files).Traceln("Starting lintFiles")
+	s.inputFiles = files
+
+	var violations []Violation
+	for _, f := range files {
+		if sortsAlone(f) && !isTestFile(f) {
+			violation, err := s.lintWholeFile(f)
+			if err != nil {
+				return nil, fmt.Errorf("could not lint file %s: %w", f, err)
+			}
+			if violation != nil {
+				violations = append(violations, *violation)
+			}
+			continue
+		}
+
+		fileViolations, err := s.lintFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("could not lint file %s: %w", f, err)
+		}
+		violations = append(violations, fileViolations...)
+	}
+
+	gosort.SliceStable(violations, func(i, j int) bool {
+		a, b := violations[i], violations[j]
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return violations, nil
+}
+
+// lintFile lints the blocks of a native file. Test and mock data files are
+// linted with the rules of testFileSorter.
+func (s *Sorter) lintFile(f string) ([]Violation, error) {
+	if isTestFile(f) && !s.testProfile {
+		return s.testFileSorter().lintFile(f)
+	}
+
+	body, err := s.parseHclFile(f)
+	if err != nil {
+		return nil, err
+	}
+
+	// With group-by-type, blocks that belong in another file are reported
+	// and left out of the ordering check.
+	var violations []Violation
+	var blocks hclsyntax.Blocks
+	for _, block := range body.Blocks {
+		if s.params.GroupByType {
+			outputKey, err := s.getOutputFileForBlock(block)
+			if err != nil {
+				return nil, fmt.Errorf("could not route block: %w", err)
+			}
+			if outputKey != getFileNameFromPath(f) {
+				violations = append(violations, newViolation(block.TypeRange.Start, f,
+					"%s block belongs in %s", block.Type, outputKey))
+				continue
+			}
+		}
+		blocks = append(blocks, block)
+	}
+
+	if s.params.Scope != scopeBodies {
+		violations = append(violations, s.lintBlockOrder(blocks, f)...)
+	}
+	for _, block := range body.Blocks {
+		violations = append(violations, s.lintBlockBody(block, f, 1, blockDisplayName(block))...)
+	}
+	return violations, nil
+}
+
+// lintWholeFile reports a JSON or variable definitions file that differs
+// from its sorted form. The whole file is reported at its first line.
+func (s *Sorter) lintWholeFile(path string) (*Violation, error) {
+	content, err := s.afs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sorted, err := s.sortFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if string(sorted[getFileNameFromPath(path)]) == string(content) {
+		return nil, nil
+	}
+	violation := newViolation(hcl.InitialPos, path, "file is not sorted")
+	return &violation, nil
+}
+
+// lintBlockOrder reports top-level blocks that are out of order. Only the
+// blocks outside the longest run that is already in order are reported, so
+// one misplaced block produces one violation.
+func (s *Sorter) lintBlockOrder(blocks hclsyntax.Blocks, filename string) []Violation {
+	expected := make(hclsyntax.Blocks, len(blocks))
+	copy(expected, blocks)
+	s.orderBlocks(expected)
+
+	index := make(map[*hclsyntax.Block]int, len(expected))
+	for i, block := range expected {
+		index[block] = i
+	}
+	ranks := make([]int, len(blocks))
+	for i, block := range blocks {
+		ranks[i] = index[block]
+	}
+
+	var violations []Violation
+	for _, i := range outOfOrder(ranks, nil) {
+		block := blocks[i]
+		if next := ranks[i] + 1; next < len(expected) {
+			violations = append(violations, newViolation(block.TypeRange.Start, filename,
+				"%s should come before %s", blockLabelName(block), blockLabelName(expected[next])))
+		} else {
+			violations = append(violations, newViolation(block.TypeRange.Start, filename,
+				"%s should come after %s", blockLabelName(block), blockLabelName(expected[ranks[i]-1])))
+		}
+	}
+	return violations
+}
+
+// lintBlockBody reports arguments and nested blocks of block that are out
+// of order, then lints the nested blocks. depth is the nesting depth of
+// block and name describes it in messages.
+func (s *Sorter) lintBlockBody(block *hclsyntax.Block, filename string, depth int, name string) []Violation {
+	if !s.sortsBodyAt(depth) {
+		return nil
+	}
+
+	keys := s.getSortedBlockKeys(block)
+	var expected []string
+	bucket := map[int]int{}
+	for i := 0; i < 3; i++ {
+		for _, key := range keys[i] {
+			bucket[len(expected)] = i
+			expected = append(expected, key)
+		}
+	}
+
+	// The items of the body in source order.
+	type bodyItem struct {
+		key string
+		pos hcl.Pos
+	}
+	var items []bodyItem
+	for _, attribute := range block.Body.Attributes {
+		items = append(items, bodyItem{attribute.Name, attribute.SrcRange.Start})
+	}
+	for _, child := range block.Body.Blocks {
+		items = append(items, bodyItem{formatBlockKey(child), child.TypeRange.Start})
+	}
+	gosort.Slice(items, func(i, j int) bool { return items[i].pos.Byte < items[j].pos.Byte })
+
+	// Repeated keys, such as several ingress blocks, take the expected
+	// positions of that key in order.
+	positions := map[string][]int{}
+	for i, key := range expected {
+		positions[key] = append(positions[key], i)
+	}
+	// Meta-arguments weigh less than normal arguments, so that a misplaced
+	// count is reported rather than every argument in front of it.
+	ranks := make([]int, len(items))
+	weights := make([]int, len(items))
+	for i, item := range items {
+		ranks[i] = positions[item.key][0]
+		positions[item.key] = positions[item.key][1:]
+		weights[i] = 1
+		if bucket[ranks[i]] == 1 {
+			weights[i] = 2
+		}
+	}
+
+	var violations []Violation
+	for _, i := range outOfOrder(ranks, weights) {
+		key, rank := items[i].key, ranks[i]
+		var message string
+		switch {
+		case bucket[rank] == 0 && rank == 0:
+			message = fmt.Sprintf("`%s` must be the first argument in %s", key, name)
+		case bucket[rank] == 0:
+			message = fmt.Sprintf("`%s` must come right after `%s` in %s", key, expected[rank-1], name)
+		case bucket[rank] == 2 && rank == len(expected)-1:
+			message = fmt.Sprintf("`%s` must be the last argument in %s", key, name)
+		case bucket[rank] == 2:
+			message = fmt.Sprintf("`%s` must come right before `%s` in %s", key, expected[rank+1], name)
+		case rank+1 < len(expected):
+			message = fmt.Sprintf("`%s` should come before `%s` in %s", key, expected[rank+1], name)
+		default:
+			message = fmt.Sprintf("`%s` should come after `%s` in %s", key, expected[rank-1], name)
+		}
+		violations = append(violations, newViolation(items[i].pos, filename, "%s", message))
+	}
+
+	for _, child := range block.Body.Blocks {
+		childName := formatBlockKey(child) + " in " + name
+		violations = append(violations, s.lintBlockBody(child, filename, depth+1, childName)...)
+	}
+	return violations
+}
+
+// outOfOrder returns, in ascending order, the indexes of the items that
+// must move to put ranks in order: those outside the heaviest increasing
+// subsequence of ranks. weights may be nil, meaning every item weighs 1.
+// Ties keep earlier items in place, so the item that appears later in the
+// file is the one reported.
+func outOfOrder(ranks, weights []int) []int {
+	weight := func(i int) int {
+		if weights == nil {
+			return 1
+		}
+		return weights[i]
+	}
+
+	best := make([]int, len(ranks))
+	previous := make([]int, len(ranks))
+	end := -1
+	for i := range ranks {
+		best[i], previous[i] = weight(i), -1
+		for j := 0; j < i; j++ {
+			if ranks[j] < ranks[i] && best[j]+weight(i) > best[i] {
+				best[i], previous[i] = best[j]+weight(i), j
+			}
+		}
+		if end < 0 || best[i] > best[end] {
+			end = i
+		}
+	}
+
+	inOrder := make([]bool, len(ranks))
+	for i := end; i >= 0; i = previous[i] {
+		inOrder[i] = true
+	}
+
+	var indexes []int
+	for i := range ranks {
+		if !inOrder[i] {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// newViolation builds a violation at pos in filename.
+func newViolation(pos hcl.Pos, filename, format string, args ...interface{}) Violation {
+	return Violation{
+		Filename: filename,
+		Line:     pos.Line,
+		Column:   pos.Column,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+// blockLabelName describes a top-level block as written, e.g. variable "b".
+func blockLabelName(block *hclsyntax.Block) string {
+	name := block.Type
+	for _, label := range block.Labels {
+		name += fmt.Sprintf(" %q", label)
+	}
+	return name
+}
+
+// blockDisplayName describes a block by its address, e.g.
+// resource aws_instance.web.
+func blockDisplayName(block *hclsyntax.Block) string {
+	if len(block.Labels) == 0 {
+		return block.Type
+	}
+	return block.Type + " " + strings.Join(block.Labels, ".")
+}
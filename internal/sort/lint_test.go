@@ -0,0 +1,133 @@
+package sort
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestOutOfOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		ranks   []int
+		weights []int
+		want    []int
+	}{
+		{name: "sorted", ranks: []int{0, 1, 2}, want: nil},
+		{name: "swapped pair reports the later item", ranks: []int{1, 0, 2, 3}, want: []int{1}},
+		{name: "last item moved to the top", ranks: []int{3, 0, 1, 2}, want: []int{0}},
+		{name: "weights keep heavier items", ranks: []int{1, 0, 2}, weights: []int{2, 1, 1}, want: []int{1}},
+		{name: "weights override position", ranks: []int{1, 0, 2}, weights: []int{1, 2, 1}, want: []int{0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := outOfOrder(tt.ranks, tt.weights); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("outOfOrder(%v, %v) = %v, want %v", tt.ranks, tt.weights, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintFiles(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/lint", 0755)
+	_ = afero.WriteFile(memFS, "/lint/main.tf", []byte(`variable "c" {}
+
+variable "b" {}
+
+resource "aws_instance" "web" {
+  ami   = "x"
+  count = 2
+
+  lifecycle {
+    prevent_destroy       = true
+    create_before_destroy = true
+  }
+}
+
+output "id" {
+  value = 1
+}
+`), 0644)
+
+	tests := []struct {
+		name   string
+		params Params
+		want   []string
+	}{
+		{
+			name:   "default rules",
+			params: Params{},
+			want: []string{
+				`/lint/main.tf:3:1: variable "b" should come before variable "c"`,
+				"/lint/main.tf:7:3: `count` must be the first argument in resource aws_instance.web",
+				"/lint/main.tf:11:5: `create_before_destroy` should come before `prevent_destroy` in lifecycle in resource aws_instance.web",
+			},
+		},
+		{
+			name:   "group-by-type",
+			params: Params{GroupByType: true, Scope: scopeBlocks},
+			want: []string{
+				"/lint/main.tf:1:1: variable block belongs in variables.tf",
+				"/lint/main.tf:3:1: variable block belongs in variables.tf",
+				"/lint/main.tf:15:1: output block belongs in outputs.tf",
+			},
+		},
+		{
+			name:   "bodies scope",
+			params: Params{Scope: scopeBodies, BodyDepth: 1},
+			want: []string{
+				"/lint/main.tf:7:3: `count` must be the first argument in resource aws_instance.web",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			violations, err := NewSorter(&params, memFS).lint("/lint")
+			if err != nil {
+				t.Fatalf("lint() error: %v", err)
+			}
+			var got []string
+			for _, v := range violations {
+				got = append(got, v.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lint() =\n%v\nwant:\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLintSortedTestdata checks that lint agrees with sort: the sorted
+// fixtures must not produce any violation.
+func TestLintSortedTestdata(t *testing.T) {
+	dirs, err := filepath.Glob("testdata/*/sorted")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dir := range dirs {
+		t.Run(dir, func(t *testing.T) {
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) == 0 {
+				t.Skip("no files")
+			}
+			violations, err := NewSorter(&Params{}, afero.NewOsFs()).lint(dir)
+			if err != nil {
+				t.Fatalf("lint() error: %v", err)
+			}
+			for _, v := range violations {
+				t.Errorf("unexpected violation: %s", v)
+			}
+		})
+	}
+}
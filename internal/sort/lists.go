@@ -0,0 +1,145 @@
+package sort
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	hclsyntax "github.com/hashicorp/hcl/v2/hclsyntax"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultListPaths are the argument paths whose list (or map) literals are
+// sorted by sort-lists when list-paths is not set. Terraform does not
+// attach any meaning to the order of these values.
+var defaultListPaths = []string{
+	"data.*.depends_on",
+	"module.*.depends_on",
+	"module.*.providers",
+	"output.*.depends_on",
+	"resource.*.depends_on",
+	"resource.*.lifecycle.ignore_changes",
+	"resource.*.lifecycle.replace_triggered_by",
+}
+
+// setFunctionName is the function whose tuple argument is always sorted by
+// sort-lists, since the result is an unordered set.
+const setFunctionName = "toset"
+
+// blockPathSegment returns the path segment of a top-level block: its type
+// followed by its first label, if any (e.g. "resource.aws_instance").
+func blockPathSegment(block *hclsyntax.Block) string {
+	if len(block.Labels) == 0 {
+		return block.Type
+	}
+	return block.Type + "." + block.Labels[0]
+}
+
+// listPaths returns the argument paths sorted by sort-lists.
+func (s *Sorter) listPaths() []string {
+	if len(s.params.ListPaths) > 0 {
+		return s.params.ListPaths
+	}
+	return defaultListPaths
+}
+
+// matchListPath reports whether the dotted argument path matches pattern.
+// Each dot-separated segment of pattern is a glob matched against the
+// corresponding segment of argumentPath.
+func matchListPath(pattern, argumentPath string) bool {
+	patternSegments := strings.Split(pattern, ".")
+	pathSegments := strings.Split(argumentPath, ".")
+	if len(patternSegments) != len(pathSegments) {
+		return false
+	}
+	for i, segment := range patternSegments {
+		if ok, _ := path.Match(segment, pathSegments[i]); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// validateListPaths reports list-paths entries that are not valid patterns.
+func validateListPaths(patterns []string) error {
+	for _, pattern := range patterns {
+		for _, segment := range strings.Split(pattern, ".") {
+			if segment == "" {
+				return fmt.Errorf("invalid list path %q: empty segment", pattern)
+			}
+			if _, err := path.Match(segment, ""); err != nil {
+				return fmt.Errorf("invalid list path %q: %w", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// sortListLiterals sorts the elements of list literals in the source text of
+// the attribute at argumentPath. The attribute's own list (or map, such as
+// module providers) is sorted when argumentPath matches a list path, and
+// the list passed to toset() is sorted wherever it appears. Lists holding
+// computed expressions are left alone.
+func (s *Sorter) sortListLiterals(argumentPath, name string, text []byte) []byte {
+	log.WithField("argumentPath", argumentPath).Traceln("Starting sortListLiterals")
+
+	if !s.params.SortLists {
+		return text
+	}
+
+	attribute, ok := parseAttributeText(name, text)
+	if !ok {
+		log.WithField("attribute.Name", name).Debugln("Could not re-parse attribute, leaving lists unsorted")
+		return text
+	}
+
+	sorter := &literalSorter{src: text, less: s.collation.less, keyOf: listItemKey}
+	for _, pattern := range s.listPaths() {
+		if !matchListPath(pattern, argumentPath) {
+			continue
+		}
+		switch expr := attribute.Expr.(type) {
+		case *hclsyntax.TupleConsExpr, *hclsyntax.ObjectConsExpr:
+			sorter.nodes = append(sorter.nodes, expr)
+		}
+		break
+	}
+	_ = hclsyntax.VisitAll(attribute.Expr, func(node hclsyntax.Node) hcl.Diagnostics {
+		call, ok := node.(*hclsyntax.FunctionCallExpr)
+		if !ok || call.Name != setFunctionName || len(call.Args) != 1 || call.ExpandFinal {
+			return nil
+		}
+		if tuple, ok := call.Args[0].(*hclsyntax.TupleConsExpr); ok {
+			sorter.nodes = append(sorter.nodes, tuple)
+		}
+		return nil
+	})
+	if len(sorter.nodes) == 0 {
+		return text
+	}
+
+	return sorter.sortText()
+}
+
+// listItemKey returns the sort key of a list element or of a map key such
+// as a module providers entry. Only literals and plain references can be
+// moved; anything computed is rejected.
+func listItemKey(source string, expr hclsyntax.Expression) (string, bool) {
+	source = strings.TrimSpace(source)
+	if key, ok := expr.(*hclsyntax.ObjectConsKeyExpr); ok {
+		if name, ok := literalObjectKey(source); ok {
+			return name, true
+		}
+		expr = key.Wrapped
+	}
+	switch e := expr.(type) {
+	case *hclsyntax.LiteralValueExpr, *hclsyntax.ScopeTraversalExpr:
+		return source, true
+	case *hclsyntax.TemplateExpr:
+		if e.IsStringLiteral() {
+			return source, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,196 @@
+package sort
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSortBytesLists(t *testing.T) {
+	tests := []struct {
+		name   string
+		params Params
+		input  string
+		want   string
+	}{
+		{
+			name:   "disabled by default",
+			params: Params{},
+			input: `resource "aws_instance" "a" {
+  depends_on = [aws_vpc.b, aws_vpc.a]
+}
+`,
+			want: `resource "aws_instance" "a" {
+  depends_on = [aws_vpc.b, aws_vpc.a]
+}
+`,
+		},
+		{
+			name:   "depends_on and lifecycle",
+			params: Params{SortLists: true},
+			input: `resource "aws_instance" "a" {
+  ami = "x"
+
+  depends_on = [aws_vpc.b, aws_vpc.a]
+
+  lifecycle {
+    ignore_changes = [
+      # managed by autoscaling
+      tags,
+      ami
+    ]
+  }
+}
+`,
+			want: `resource "aws_instance" "a" {
+  ami = "x"
+
+  depends_on = [aws_vpc.a, aws_vpc.b]
+
+  lifecycle {
+    ignore_changes = [
+      ami,
+      # managed by autoscaling
+      tags,
+    ]
+  }
+}
+`,
+		},
+		{
+			name:   "module providers map",
+			params: Params{SortLists: true},
+			input: `module "m" {
+  source = "./m"
+
+  providers = {
+    google   = google.main
+    aws.west = aws.west
+    aws      = aws.east
+  }
+}
+`,
+			want: `module "m" {
+  source = "./m"
+  providers = {
+    aws      = aws.east
+    aws.west = aws.west
+    google   = google.main
+  }
+}
+`,
+		},
+		{
+			name:   "toset literal",
+			params: Params{SortLists: true},
+			input: `locals {
+  zones = toset(["b", "c", "a"])
+}
+`,
+			want: `locals {
+  zones = toset(["a", "b", "c"])
+}
+`,
+		},
+		{
+			name:   "computed elements keep their order",
+			params: Params{SortLists: true},
+			input: `resource "aws_instance" "a" {
+  depends_on = [aws_vpc.b, var.enabled ? aws_vpc.a : aws_vpc.c]
+}
+`,
+			want: `resource "aws_instance" "a" {
+  depends_on = [aws_vpc.b, var.enabled ? aws_vpc.a : aws_vpc.c]
+}
+`,
+		},
+		{
+			name:   "paths outside list-paths are untouched",
+			params: Params{SortLists: true},
+			input: `resource "aws_instance" "a" {
+  security_groups = ["b", "a"]
+}
+`,
+			want: `resource "aws_instance" "a" {
+  security_groups = ["b", "a"]
+}
+`,
+		},
+		{
+			name:   "custom list-paths",
+			params: Params{SortLists: true, ListPaths: []string{"resource.aws_*.security_groups"}},
+			input: `resource "aws_instance" "a" {
+  depends_on      = [aws_vpc.b, aws_vpc.a]
+  security_groups = ["b", "a"]
+}
+`,
+			want: `resource "aws_instance" "a" {
+  security_groups = ["a", "b"]
+
+  depends_on = [aws_vpc.b, aws_vpc.a]
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			got, err := SortBytes([]byte(tt.input), "main.tf", &params)
+			if err != nil {
+				t.Fatalf("SortBytes() error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("SortBytes() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchListPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"resource.*.lifecycle.ignore_changes", "resource.aws_instance.lifecycle.ignore_changes", true},
+		{"resource.*.lifecycle.ignore_changes", "resource.aws_instance.ignore_changes", false},
+		{"resource.aws_*.depends_on", "resource.aws_instance.depends_on", true},
+		{"resource.aws_*.depends_on", "resource.google_compute_instance.depends_on", false},
+		{"module.*.providers", "module.network.providers", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"/"+tt.path, func(t *testing.T) {
+			if got := matchListPath(tt.pattern, tt.path); got != tt.want {
+				t.Errorf("matchListPath(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateListPaths(t *testing.T) {
+	tests := []struct {
+		name    string
+		paths   []string
+		wantErr string
+	}{
+		{name: "valid", paths: []string{"resource.*.depends_on"}},
+		{name: "bad glob", paths: []string{"resource.[aws.depends_on"}, wantErr: "invalid list path"},
+		{name: "empty segment", paths: []string{"resource..depends_on"}, wantErr: "empty segment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateListPaths(tt.paths)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
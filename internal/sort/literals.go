@@ -0,0 +1,243 @@
+package sort
+
+import (
+	"bytes"
+	gosort "sort"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	hclsyntax "github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// literalSorter rewrites the object and tuple constructors of a single
+// attribute. Positions are byte offsets into src, the attribute's own source
+// text, so the rewrite never depends on the layout of the original file.
+type literalSorter struct {
+	src []byte
+	// nodes are the *hclsyntax.ObjectConsExpr and *hclsyntax.TupleConsExpr
+	// expressions to sort, ordered by start offset.
+	nodes []hclsyntax.Expression
+	// first lists keys that lead in the given order; the rest follow by less.
+	first []string
+	less  func(a, b string) bool
+	// keyOf returns the sort key of an object item key or a tuple element,
+	// or false when the item cannot be moved safely.
+	keyOf func(src string, expr hclsyntax.Expression) (string, bool)
+}
+
+// literalItem is one object item or tuple element.
+type literalItem struct {
+	key        string
+	start, end hcl.Pos
+}
+
+// literalChunk is the rendered source text of one item together with its
+// sort key.
+type literalChunk struct {
+	key  string
+	text []byte
+}
+
+// parseAttributeText re-parses the source text of a single attribute.
+func parseAttributeText(name string, text []byte) (*hclsyntax.Attribute, bool) {
+	file, diags := hclsyntax.ParseConfig(text, name+".tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, false
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, false
+	}
+	attribute, ok := body.Attributes[name]
+	return attribute, ok
+}
+
+// sortText returns the rewritten source text.
+func (l *literalSorter) sortText() []byte {
+	gosort.SliceStable(l.nodes, func(i, j int) bool {
+		return l.nodes[i].Range().Start.Byte < l.nodes[j].Range().Start.Byte
+	})
+	return l.render(0, len(l.src))
+}
+
+// render returns src[start:end] with every node inside the range replaced by
+// its sorted form.
+func (l *literalSorter) render(start, end int) []byte {
+	var output []byte
+	pos := start
+	for _, node := range l.nodes {
+		r := node.Range()
+		// Nodes nested in a node that was already rendered are handled by
+		// that node's items.
+		if r.Start.Byte < pos || r.End.Byte > end {
+			continue
+		}
+		output = append(output, l.src[pos:r.Start.Byte]...)
+		output = append(output, l.renderNode(node)...)
+		pos = r.End.Byte
+	}
+	return append(output, l.src[pos:end]...)
+}
+
+// renderNode returns the text of node with its items sorted. Nodes whose
+// items cannot be moved safely keep their order; nodes nested inside them
+// are still sorted.
+func (l *literalSorter) renderNode(node hclsyntax.Expression) []byte {
+	open, items, ok := l.items(node)
+	if !ok || len(items) < 2 {
+		return l.renderUnsorted(node, open)
+	}
+
+	_, tuple := node.(*hclsyntax.TupleConsExpr)
+	var output []byte
+	if items[0].start.Line > open.Start.Line {
+		output, ok = l.renderMultiLine(node, open, items, tuple)
+	} else {
+		output, ok = l.renderSingleLine(node, items)
+	}
+	if !ok {
+		return l.renderUnsorted(node, open)
+	}
+	return output
+}
+
+// items returns the opening bracket range and the items of node.
+func (l *literalSorter) items(node hclsyntax.Expression) (hcl.Range, []literalItem, bool) {
+	var items []literalItem
+	switch n := node.(type) {
+	case *hclsyntax.ObjectConsExpr:
+		for _, item := range n.Items {
+			r := item.KeyExpr.Range()
+			key, ok := l.keyOf(string(l.src[r.Start.Byte:r.End.Byte]), item.KeyExpr)
+			if !ok {
+				return n.OpenRange, nil, false
+			}
+			items = append(items, literalItem{key: key, start: r.Start, end: item.ValueExpr.Range().End})
+		}
+		return n.OpenRange, items, true
+	case *hclsyntax.TupleConsExpr:
+		for _, expr := range n.Exprs {
+			r := expr.Range()
+			key, ok := l.keyOf(string(l.src[r.Start.Byte:r.End.Byte]), expr)
+			if !ok {
+				return n.OpenRange, nil, false
+			}
+			items = append(items, literalItem{key: key, start: r.Start, end: r.End})
+		}
+		return n.OpenRange, items, true
+	}
+	return node.StartRange(), nil, false
+}
+
+// renderUnsorted returns the text of node in its original item order with
+// the nodes nested inside it sorted.
+func (l *literalSorter) renderUnsorted(node hclsyntax.Expression, open hcl.Range) []byte {
+	output := append([]byte{}, l.src[node.Range().Start.Byte:open.End.Byte]...)
+	return append(output, l.render(open.End.Byte, node.Range().End.Byte)...)
+}
+
+// renderMultiLine sorts a node written with one item per line. Each item
+// carries the comment lines above it and any comment after it on the same
+// line. Tuple elements get a trailing comma so that the last element can
+// move up.
+func (l *literalSorter) renderMultiLine(node hclsyntax.Expression, open hcl.Range, items []literalItem, tuple bool) ([]byte, bool) {
+	nodeRange := node.Range()
+	closing := nodeRange.End.Byte - 1
+	chunkStart := lineEnd(l.src, open.End.Byte)
+	prefix := l.render(nodeRange.Start.Byte, chunkStart)
+
+	chunks := make([]literalChunk, len(items))
+	previousLine := open.Start.Line
+	for i, item := range items {
+		if item.start.Line <= previousLine {
+			return nil, false
+		}
+		chunkEnd := lineEnd(l.src, item.end.Byte)
+		if chunkEnd > closing {
+			return nil, false
+		}
+		text := l.render(chunkStart, item.end.Byte)
+		rest := l.render(item.end.Byte, chunkEnd)
+		if tuple && !bytes.HasPrefix(bytes.TrimLeft(rest, " \t"), []byte(",")) {
+			text = append(text, ',')
+		}
+		chunks[i] = literalChunk{key: item.key, text: trimLeadingBlankLines(append(text, rest...))}
+		chunkStart = chunkEnd
+		previousLine = item.end.Line
+	}
+	suffix := l.render(chunkStart, nodeRange.End.Byte)
+
+	l.sortChunks(chunks)
+
+	output := prefix
+	for _, chunk := range chunks {
+		output = append(output, chunk.text...)
+	}
+	return append(output, suffix...), true
+}
+
+// renderSingleLine sorts a node whose items share lines, such as
+// { b = 2, a = 1 } or ["b", "a"], keeping the separators between items in
+// place. Nodes containing comments are left alone.
+func (l *literalSorter) renderSingleLine(node hclsyntax.Expression, items []literalItem) ([]byte, bool) {
+	nodeRange := node.Range()
+	text := l.src[nodeRange.Start.Byte:nodeRange.End.Byte]
+	if bytes.Contains(text, []byte("#")) || bytes.Contains(text, []byte("//")) || bytes.Contains(text, []byte("/*")) {
+		return nil, false
+	}
+
+	chunks := make([]literalChunk, len(items))
+	for i, item := range items {
+		chunks[i] = literalChunk{key: item.key, text: l.render(item.start.Byte, item.end.Byte)}
+	}
+	l.sortChunks(chunks)
+
+	output := l.render(nodeRange.Start.Byte, items[0].start.Byte)
+	for i, chunk := range chunks {
+		output = append(output, chunk.text...)
+		gapEnd := nodeRange.End.Byte
+		if i < len(items)-1 {
+			gapEnd = items[i+1].start.Byte
+		}
+		output = append(output, l.src[items[i].end.Byte:gapEnd]...)
+	}
+	return output, true
+}
+
+// sortChunks orders chunks by the leading keys, then by key.
+func (l *literalSorter) sortChunks(chunks []literalChunk) {
+	rank := func(key string) int {
+		for i, first := range l.first {
+			if key == first {
+				return i
+			}
+		}
+		return len(l.first)
+	}
+	gosort.SliceStable(chunks, func(i, j int) bool {
+		ri, rj := rank(chunks[i].key), rank(chunks[j].key)
+		if ri != rj {
+			return ri < rj
+		}
+		return l.less(chunks[i].key, chunks[j].key)
+	})
+}
+
+// lineEnd returns the offset just past the newline that ends the line
+// containing offset, or len(src) on the last line.
+func lineEnd(src []byte, offset int) int {
+	if i := bytes.IndexByte(src[offset:], '\n'); i >= 0 {
+		return offset + i + 1
+	}
+	return len(src)
+}
+
+// trimLeadingBlankLines removes whitespace-only lines from the start of text.
+func trimLeadingBlankLines(text []byte) []byte {
+	for {
+		i := bytes.IndexByte(text, '\n')
+		if i < 0 || len(bytes.TrimSpace(text[:i])) > 0 {
+			return text
+		}
+		text = text[i+1:]
+	}
+}
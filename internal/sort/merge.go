@@ -0,0 +1,240 @@
+package sort
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	hclsyntax "github.com/hashicorp/hcl/v2/hclsyntax"
+	log "github.com/sirupsen/logrus"
+)
+
+// mergeableBlockTypes are the top-level block types folded into a single
+// block per output file by merge-blocks.
+var mergeableBlockTypes = []string{"locals", "terraform"}
+
+// mergeableChildBlockTypes are the nested block types folded into one when
+// their parent blocks are merged.
+var mergeableChildBlockTypes = map[string][]string{
+	"terraform": {"required_providers"},
+}
+
+// combinedSource records where one input file starts in the combined file
+// built by group-by-type.
+type combinedSource struct {
+	path      string
+	startLine int
+}
+
+// sourceLocation returns the "file:line" of pos, translating positions in
+// the combined group-by-type file back to the input file they came from.
+func (s *Sorter) sourceLocation(pos hcl.Pos, filename string) string {
+	if filename == combinedFileName {
+		for i := len(s.combinedSources) - 1; i >= 0; i-- {
+			source := s.combinedSources[i]
+			if pos.Line >= source.startLine {
+				return fmt.Sprintf("%s:%d", source.path, pos.Line-source.startLine+1)
+			}
+		}
+	}
+	return fmt.Sprintf("%s:%d", filename, pos.Line)
+}
+
+// mergeBlocks folds the locals and terraform blocks that are written to the
+// same output file into the first of them. outputKeys holds the output file
+// of each block. Arguments defined by more than one block must have the same
+// value; identical duplicates are kept once.
+func (s *Sorter) mergeBlocks(blocks hclsyntax.Blocks, outputKeys []string) (hclsyntax.Blocks, []string, error) {
+	log.WithField("blocks", blocks).Traceln("Starting mergeBlocks")
+
+	type groupKey struct{ blockType, outputKey string }
+	groups := map[groupKey][]*hclsyntax.Block{}
+	for i, block := range blocks {
+		if slices.Contains(mergeableBlockTypes, block.Type) {
+			key := groupKey{block.Type, outputKeys[i]}
+			groups[key] = append(groups[key], block)
+		}
+	}
+
+	var mergedBlocks hclsyntax.Blocks
+	var mergedKeys []string
+	for i, block := range blocks {
+		if !slices.Contains(mergeableBlockTypes, block.Type) {
+			mergedBlocks = append(mergedBlocks, block)
+			mergedKeys = append(mergedKeys, outputKeys[i])
+			continue
+		}
+		group := groups[groupKey{block.Type, outputKeys[i]}]
+		if group[0] != block {
+			// Folded into the first block of its group.
+			continue
+		}
+		merged, err := s.foldBlocks(group, 1)
+		if err != nil {
+			return nil, nil, err
+		}
+		mergedBlocks = append(mergedBlocks, merged)
+		mergedKeys = append(mergedKeys, outputKeys[i])
+	}
+
+	return mergedBlocks, mergedKeys, nil
+}
+
+// foldBlocks returns a single block holding the arguments and nested blocks
+// of every block in group. depth is the nesting depth of the group.
+func (s *Sorter) foldBlocks(group []*hclsyntax.Block, depth int) (*hclsyntax.Block, error) {
+	first := group[0]
+	if len(group) == 1 && len(mergeableChildBlockTypes[first.Type]) == 0 {
+		return first, nil
+	}
+
+	body := &hclsyntax.Body{
+		Attributes: hclsyntax.Attributes{},
+		SrcRange:   first.Body.SrcRange,
+		EndRange:   first.Body.EndRange,
+	}
+	merged := &hclsyntax.Block{
+		Type:            first.Type,
+		Labels:          first.Labels,
+		Body:            body,
+		TypeRange:       first.TypeRange,
+		LabelRanges:     first.LabelRanges,
+		OpenBraceRange:  first.OpenBraceRange,
+		CloseBraceRange: first.CloseBraceRange,
+	}
+
+	for i, block := range group {
+		if i > 0 {
+			s.addFoldedComment(merged, block)
+		}
+		for _, name := range sortedAttributeNames(block.Body.Attributes) {
+			attribute := block.Body.Attributes[name]
+			existing, ok := body.Attributes[name]
+			if !ok {
+				body.Attributes[name] = attribute
+				continue
+			}
+			same, err := s.sameExpression(existing, attribute)
+			if err != nil {
+				return nil, err
+			}
+			if !same {
+				return nil, fmt.Errorf("cannot merge %s blocks: %q is defined at %s and %s with different values",
+					first.Type, name,
+					s.sourceLocation(existing.SrcRange.Start, existing.SrcRange.Filename),
+					s.sourceLocation(attribute.SrcRange.Start, attribute.SrcRange.Filename))
+			}
+		}
+		body.Blocks = append(body.Blocks, block.Body.Blocks...)
+	}
+
+	// Nested blocks are only folded when their bodies are rendered from
+	// the merged block rather than copied verbatim.
+	childTypes := mergeableChildBlockTypes[first.Type]
+	if len(childTypes) == 0 || !s.sortsBodyAt(depth+1) {
+		return merged, nil
+	}
+	var childBlocks hclsyntax.Blocks
+	for _, child := range body.Blocks {
+		if !slices.Contains(childTypes, child.Type) {
+			childBlocks = append(childBlocks, child)
+			continue
+		}
+		var childGroup []*hclsyntax.Block
+		for _, candidate := range body.Blocks {
+			if candidate.Type == child.Type {
+				childGroup = append(childGroup, candidate)
+			}
+		}
+		if childGroup[0] != child {
+			continue
+		}
+		foldedChild, err := s.foldBlocks(childGroup, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		childBlocks = append(childBlocks, foldedChild)
+	}
+	body.Blocks = childBlocks
+
+	return merged, nil
+}
+
+// sameExpression reports whether two attributes have the same value,
+// ignoring differences in whitespace.
+func (s *Sorter) sameExpression(a, b *hclsyntax.Attribute) (bool, error) {
+	aText, err := s.getExpressionText(a.Expr)
+	if err != nil {
+		return false, err
+	}
+	bText, err := s.getExpressionText(b.Expr)
+	if err != nil {
+		return false, err
+	}
+	return strings.Join(strings.Fields(aText), " ") == strings.Join(strings.Fields(bText), " "), nil
+}
+
+// getExpressionText returns the source text of an expression.
+func (s *Sorter) getExpressionText(expr hclsyntax.Expression) (string, error) {
+	r := expr.Range()
+	lines, err := s.getLinesFromFile(r.Filename)
+	if err != nil {
+		return "", fmt.Errorf("could not get lines from file: %w", err)
+	}
+
+	var text []string
+	for i := r.Start.Line - 1; i <= r.End.Line-1 && i < len(lines); i++ {
+		line := lines[i]
+		if i == r.End.Line-1 {
+			line = line[:r.End.Column-1]
+		}
+		if i == r.Start.Line-1 {
+			line = line[r.Start.Column-1:]
+		}
+		text = append(text, line)
+	}
+	return strings.Join(text, "\n"), nil
+}
+
+// addFoldedComment records the leading comment of block, which is folded
+// into merged, so that it is written above merged.
+func (s *Sorter) addFoldedComment(merged, block *hclsyntax.Block) {
+	if s.params.RemoveComments {
+		return
+	}
+	lines, err := s.getLinesFromFile(block.TypeRange.Filename)
+	if err != nil {
+		return
+	}
+	comment := s.getNodeComment(lines, block.TypeRange.Start.Line-1, block.TypeRange.Filename)
+	if len(comment) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.foldedComments == nil {
+		s.foldedComments = make(map[*hclsyntax.Block][]string)
+	}
+	s.foldedComments[merged] = append(s.foldedComments[merged], comment...)
+}
+
+// getFoldedComment returns the comments of the blocks folded into block.
+func (s *Sorter) getFoldedComment(block *hclsyntax.Block) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.foldedComments[block]
+}
+
+// sortedAttributeNames returns the attribute names in source order.
+func sortedAttributeNames(attributes hclsyntax.Attributes) []string {
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		return attributes[a].SrcRange.Start.Byte - attributes[b].SrcRange.Start.Byte
+	})
+	return names
+}
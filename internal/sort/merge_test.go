@@ -0,0 +1,144 @@
+package sort
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestSortBytesMergeBlocks(t *testing.T) {
+	input := `# Shared naming
+locals {
+  name = "app"
+}
+
+terraform {
+  required_version = ">= 1.5"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+# Derived values
+locals {
+  # Full name
+  full_name = "${local.name}-prod"
+  name      = "app"
+}
+
+terraform {
+  required_providers {
+    google = {
+      source = "hashicorp/google"
+    }
+  }
+}
+`
+
+	t.Run("disabled by default", func(t *testing.T) {
+		got, err := SortBytes([]byte(input), "main.tf", &Params{})
+		if err != nil {
+			t.Fatalf("SortBytes() error: %v", err)
+		}
+		if n := strings.Count(string(got), "locals {"); n != 2 {
+			t.Errorf("expected 2 locals blocks, got %d:\n%s", n, got)
+		}
+	})
+
+	t.Run("merges locals and terraform", func(t *testing.T) {
+		got, err := SortBytes([]byte(input), "main.tf", &Params{MergeBlocks: true})
+		if err != nil {
+			t.Fatalf("SortBytes() error: %v", err)
+		}
+		want := `terraform {
+  required_version = ">= 1.5"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+    google = {
+      source = "hashicorp/google"
+    }
+  }
+}
+
+# Shared naming
+# Derived values
+locals {
+  # Full name
+  full_name = "${local.name}-prod"
+  name      = "app"
+}
+`
+		if string(got) != want {
+			t.Errorf("SortBytes() =\n%s\nwant:\n%s", got, want)
+		}
+	})
+
+	t.Run("conflicting values", func(t *testing.T) {
+		conflicting := `locals {
+  name = "app"
+}
+
+locals {
+  name = "other"
+}
+`
+		_, err := SortBytes([]byte(conflicting), "main.tf", &Params{MergeBlocks: true})
+		if err == nil {
+			t.Fatal("expected conflict error, got nil")
+		}
+		for _, want := range []string{`"name"`, "main.tf:2", "main.tf:6"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("expected error to contain %q, got: %v", want, err)
+			}
+		}
+	})
+
+	t.Run("conflicts with blocks scope", func(t *testing.T) {
+		_, err := SortBytes([]byte(input), "main.tf", &Params{MergeBlocks: true, Scope: scopeBlocks})
+		if err == nil || !strings.Contains(err.Error(), "merge-blocks") {
+			t.Fatalf("expected merge-blocks scope error, got: %v", err)
+		}
+	})
+}
+
+func TestMergeBlocksGroupByType(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/src", 0755)
+	_ = afero.WriteFile(memFS, "/src/a.tf", []byte("locals {\n  region = \"us-east-1\"\n}\n"), 0644)
+	_ = afero.WriteFile(memFS, "/src/b.tf", []byte("locals {\n  zone = \"a\"\n}\n"), 0644)
+	_ = afero.WriteFile(memFS, "/src/c.tf", []byte("\nlocals {\n  region = \"eu-west-1\"\n}\n"), 0644)
+
+	t.Run("merged into one block", func(t *testing.T) {
+		s := NewSorter(&Params{GroupByType: true, MergeBlocks: true}, memFS)
+		got, err := s.sortFiles([]string{"/src/a.tf", "/src/b.tf"})
+		if err != nil {
+			t.Fatalf("sortFiles() error: %v", err)
+		}
+		want := "locals {\n  region = \"us-east-1\"\n  zone   = \"a\"\n}\n"
+		if string(got["locals.tf"]) != want {
+			t.Errorf("locals.tf =\n%s\nwant:\n%s", got["locals.tf"], want)
+		}
+	})
+
+	t.Run("conflict reports input files", func(t *testing.T) {
+		s := NewSorter(&Params{GroupByType: true, MergeBlocks: true}, memFS)
+		_, err := s.sortFiles([]string{"/src/a.tf", "/src/c.tf"})
+		if err == nil {
+			t.Fatal("expected conflict error, got nil")
+		}
+		for _, want := range []string{"/src/a.tf:2", "/src/c.tf:3"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("expected error to contain %q, got: %v", want, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package sort
+
+import (
+	"slices"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	hclsyntax "github.com/hashicorp/hcl/v2/hclsyntax"
+	log "github.com/sirupsen/logrus"
+)
+
+// requiredProvidersBlockType is the terraform sub-block whose attributes are
+// provider requirement objects.
+const requiredProvidersBlockType = "required_providers"
+
+// defaultObjectKeyArguments is the argument allowlist used by sort-object-keys
+// when object-key-arguments is not set.
+var defaultObjectKeyArguments = []string{"labels", "tags", "type"}
+
+// providerRequirementKeys is the canonical leading key order of a provider
+// requirement object; any other keys follow alphabetically.
+var providerRequirementKeys = []string{"source", "version"}
+
+// objectKeyArguments returns the argument names whose object values are
+// sorted by sort-object-keys.
+func (s *Sorter) objectKeyArguments() []string {
+	if len(s.params.ObjectKeyArguments) > 0 {
+		return s.params.ObjectKeyArguments
+	}
+	return defaultObjectKeyArguments
+}
+
+// sortObjectKeys sorts the keys of object constructors in the source text of
+// an attribute of block. Every object inside an allowlisted argument is
+// sorted alphabetically; provider requirement objects inside
+// required_providers put source and version first. The text is returned
+// unchanged when the option is off, the argument does not qualify or the
+// objects cannot be reordered safely.
+func (s *Sorter) sortObjectKeys(block *hclsyntax.Block, name string, text []byte) []byte {
+	log.WithFields(log.Fields{blockTypeLabel: block.Type, "attribute.Name": name}).Traceln("Starting sortObjectKeys")
+
+	if !s.params.SortObjectKeys {
+		return text
+	}
+
+	requirement := block.Type == requiredProvidersBlockType
+	if !requirement && !slices.Contains(s.objectKeyArguments(), name) {
+		return text
+	}
+
+	attribute, ok := parseAttributeText(name, text)
+	if !ok {
+		log.WithField("attribute.Name", name).Debugln("Could not re-parse attribute, leaving object keys unsorted")
+		return text
+	}
+
+	sorter := &literalSorter{src: text, less: s.collation.less, keyOf: objectItemKey}
+	if requirement {
+		// Only the requirement object itself is canonicalised.
+		obj, ok := attribute.Expr.(*hclsyntax.ObjectConsExpr)
+		if !ok {
+			return text
+		}
+		sorter.nodes = []hclsyntax.Expression{obj}
+		sorter.first = providerRequirementKeys
+	} else {
+		_ = hclsyntax.VisitAll(attribute.Expr, func(node hclsyntax.Node) hcl.Diagnostics {
+			if obj, ok := node.(*hclsyntax.ObjectConsExpr); ok {
+				sorter.nodes = append(sorter.nodes, obj)
+			}
+			return nil
+		})
+	}
+	if len(sorter.nodes) == 0 {
+		return text
+	}
+
+	return sorter.sortText()
+}
+
+// objectItemKey returns the sort key of an object item key.
+func objectItemKey(source string, _ hclsyntax.Expression) (string, bool) {
+	return literalObjectKey(source)
+}
+
+// literalObjectKey returns the name of an object key written as a bare
+// identifier or a plain quoted string. Keys that contain expressions,
+// templates or escapes are not literal.
+func literalObjectKey(source string) (string, bool) {
+	source = strings.TrimSpace(source)
+	if hclsyntax.ValidIdentifier(source) {
+		return source, true
+	}
+	if len(source) < 2 || source[0] != '"' || source[len(source)-1] != '"' {
+		return "", false
+	}
+	inner := source[1 : len(source)-1]
+	if strings.ContainsAny(inner, "\"\\") || strings.Contains(inner, "${") || strings.Contains(inner, "%{") {
+		return "", false
+	}
+	return inner, true
+}
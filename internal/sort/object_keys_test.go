@@ -0,0 +1,215 @@
+package sort
+
+import (
+	"testing"
+)
+
+func TestSortBytesObjectKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		params Params
+		input  string
+		want   string
+	}{
+		{
+			name:   "disabled by default",
+			params: Params{},
+			input: `resource "aws_s3_bucket" "b" {
+  tags = {
+    Name = "b"
+    Env  = "dev"
+  }
+}
+`,
+			want: `resource "aws_s3_bucket" "b" {
+  tags = {
+    Name = "b"
+    Env  = "dev"
+  }
+}
+`,
+		},
+		{
+			name:   "tags keep comments on their items",
+			params: Params{SortObjectKeys: true},
+			input: `resource "aws_s3_bucket" "b" {
+  tags = {
+    # Human readable name
+    Name = "b"
+    Env  = "dev" # deployment stage
+    "Cost-Center" = "42"
+  }
+}
+`,
+			want: `resource "aws_s3_bucket" "b" {
+  tags = {
+    "Cost-Center" = "42"
+    Env           = "dev" # deployment stage
+    # Human readable name
+    Name = "b"
+  }
+}
+`,
+		},
+		{
+			name:   "nested object type constraint",
+			params: Params{SortObjectKeys: true},
+			input: `variable "settings" {
+  type = object({
+    size = number
+    network = object({
+      subnet = string
+      cidr   = string
+    })
+    enabled = bool
+  })
+}
+`,
+			want: `variable "settings" {
+  type = object({
+    enabled = bool
+    network = object({
+      cidr   = string
+      subnet = string
+    })
+    size = number
+  })
+}
+`,
+		},
+		{
+			name:   "single-line object",
+			params: Params{SortObjectKeys: true},
+			input: `resource "aws_s3_bucket" "b" {
+  tags = { b = "2", a = "1" }
+}
+`,
+			want: `resource "aws_s3_bucket" "b" {
+  tags = { a = "1", b = "2" }
+}
+`,
+		},
+		{
+			name:   "arguments outside the allowlist are untouched",
+			params: Params{SortObjectKeys: true},
+			input: `locals {
+  config = {
+    b = 2
+    a = 1
+  }
+}
+`,
+			want: `locals {
+  config = {
+    b = 2
+    a = 1
+  }
+}
+`,
+		},
+		{
+			name:   "custom allowlist",
+			params: Params{SortObjectKeys: true, ObjectKeyArguments: []string{"config"}},
+			input: `locals {
+  config = {
+    b = 2
+    a = 1
+  }
+  tags = {
+    b = 2
+    a = 1
+  }
+}
+`,
+			want: `locals {
+  config = {
+    a = 1
+    b = 2
+  }
+  tags = {
+    b = 2
+    a = 1
+  }
+}
+`,
+		},
+		{
+			name:   "computed keys keep their order",
+			params: Params{SortObjectKeys: true},
+			input: `resource "aws_s3_bucket" "b" {
+  tags = {
+    (var.key) = "x"
+    Env       = "dev"
+  }
+}
+`,
+			want: `resource "aws_s3_bucket" "b" {
+  tags = {
+    (var.key) = "x"
+    Env       = "dev"
+  }
+}
+`,
+		},
+		{
+			name:   "provider requirements put source and version first",
+			params: Params{SortObjectKeys: true},
+			input: `terraform {
+  required_providers {
+    aws = {
+      version               = "~> 5.0"
+      configuration_aliases = [aws.east]
+      source                = "hashicorp/aws"
+    }
+  }
+}
+`,
+			want: `terraform {
+  required_providers {
+    aws = {
+      source                = "hashicorp/aws"
+      version               = "~> 5.0"
+      configuration_aliases = [aws.east]
+    }
+  }
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			got, err := SortBytes([]byte(tt.input), "main.tf", &params)
+			if err != nil {
+				t.Fatalf("SortBytes() error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("SortBytes() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLiteralObjectKey(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+		wantOK bool
+	}{
+		{"Name", "Name", true},
+		{`"Cost-Center"`, "Cost-Center", true},
+		{`"${var.prefix}-name"`, "", false},
+		{`"a\"b"`, "", false},
+		{"(var.key)", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			got, ok := literalObjectKey(tt.source)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("literalObjectKey(%q) = %q, %v; want %q, %v", tt.source, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package sort
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	gosort "sort"
+	"strings"
+	"text/tabwriter"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Formats accepted by the plan setting.
+const (
+	planText = "text"
+	planJSON = "json"
+)
+
+// manifestFileName is the name of the plan manifest written to the output
+// directory.
+const manifestFileName = "tforganize-manifest.json"
+
+// planLocation is where a block starts. Position is the 1-based index of the
+// block among the top-level blocks of its destination file.
+type planLocation struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Position int    `json:"position,omitempty"`
+}
+
+// planEntry records where one block came from and where it goes. A block
+// folded into another block by merge-blocks has the merged block as its
+// destination.
+type planEntry struct {
+	Address     string        `json:"address"`
+	Source      planLocation  `json:"source"`
+	Destination *planLocation `json:"destination"`
+	Merged      bool          `json:"merged,omitempty"`
+}
+
+// runPlan collects the plan entries of a run, including every directory of
+// a recursive run.
+type runPlan struct {
+	entries []planEntry
+}
+
+// validatePlanFormat checks the plan setting.
+func validatePlanFormat(format string) error {
+	switch format {
+	case "", planText, planJSON:
+		return nil
+	}
+	return fmt.Errorf("unknown plan format %q (expected %s or %s)", format, planText, planJSON)
+}
+
+// planEnabled reports whether the run collects a plan.
+func (s *Sorter) planEnabled() bool {
+	return s.params.Plan != "" || s.params.Manifest
+}
+
+// recordPlan adds the source and destination of every block of inputFiles
+// to the run plan. It must run before files are written.
+func (s *Sorter) recordPlan(target string, inputFiles []string, sortedFiles map[string][]byte) error {
+	if s.plan == nil {
+		return nil
+	}
+	log.WithField("target", target).Traceln("Starting recordPlan")
+
+	before, after, err := s.parseRunFiles(target, inputFiles, sortedFiles)
+	if err != nil {
+		return fmt.Errorf("plan: %w", err)
+	}
+
+	positions := map[*summaryFile]map[int]int{} // block start byte to position
+	for _, f := range after {
+		positions[f] = map[int]int{}
+		for i, block := range f.body.Blocks {
+			positions[f][block.TypeRange.Start.Byte] = i + 1
+		}
+	}
+
+	beforeBlocks, afterBlocks := summaryBlocks(before), summaryBlocks(after)
+	for address, olds := range beforeBlocks {
+		news := afterBlocks[address]
+		for i, old := range olds {
+			entry := planEntry{
+				Address: summaryName(address, i, max(len(olds), len(news))),
+				Source:  planLocation{File: reportPath(old.file.path), Line: old.block.TypeRange.Start.Line},
+			}
+			updated := summaryBlock{}
+			switch {
+			case i < len(news):
+				updated = news[i]
+			case len(news) > 0:
+				updated = news[0]
+				entry.Merged = true
+			}
+			if updated.block != nil {
+				entry.Destination = &planLocation{
+					File:     reportPath(s.destinationPath(updated.file.path)),
+					Line:     updated.block.TypeRange.Start.Line,
+					Position: positions[updated.file][updated.block.TypeRange.Start.Byte],
+				}
+			}
+			s.plan.entries = append(s.plan.entries, entry)
+		}
+	}
+	return nil
+}
+
+// destinationPath returns where the sorted file that replaces path is
+// written: path itself, or the file of the same name in the output
+// directory.
+func (s *Sorter) destinationPath(path string) string {
+	if s.params.OutputDir != "" && !s.params.Inline {
+		return filepath.Join(s.params.OutputDir, filepath.Base(path))
+	}
+	return path
+}
+
+// writePlan prints the run plan in the plan format and writes the manifest
+// to the output directory when requested.
+func (s *Sorter) writePlan() error {
+	if s.plan == nil {
+		return nil
+	}
+	entries := s.plan.entries
+	gosort.Slice(entries, func(i, j int) bool {
+		if entries[i].Source.File != entries[j].Source.File {
+			return entries[i].Source.File < entries[j].Source.File
+		}
+		return entries[i].Source.Line < entries[j].Source.Line
+	})
+
+	switch s.params.Plan {
+	case planText:
+		if err := writeTextPlan(os.Stdout, entries); err != nil {
+			return err
+		}
+	case planJSON:
+		if err := writeJSONPlan(os.Stdout, entries); err != nil {
+			return err
+		}
+	}
+
+	if s.params.Manifest {
+		var buf strings.Builder
+		if err := writeJSONPlan(&buf, entries); err != nil {
+			return err
+		}
+		path := filepath.Join(s.params.OutputDir, manifestFileName)
+		if err := s.afs.MkdirAll(s.params.OutputDir, 0755); err != nil {
+			return fmt.Errorf("could not create the output directory: %w", err)
+		}
+		if err := s.writeFile(path, []byte(buf.String())); err != nil {
+			return fmt.Errorf("could not write manifest: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeTextPlan prints one aligned line per block:
+// address, source file:line and destination file:line.
+func writeTextPlan(w io.Writer, entries []planEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, e := range entries {
+		destination := "(removed)"
+		if e.Destination != nil {
+			destination = fmt.Sprintf("%s:%d", e.Destination.File, e.Destination.Line)
+			if e.Merged {
+				destination += " (merged)"
+			}
+		}
+		if _, err := fmt.Fprintf(tw, "%s\t%s:%d\t-> %s\n", e.Address, e.Source.File, e.Source.Line, destination); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+// writeJSONPlan writes the entries as a JSON document with a blocks list,
+// the format of both the JSON plan and the manifest.
+func writeJSONPlan(w io.Writer, entries []planEntry) error {
+	if entries == nil {
+		entries = []planEntry{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Blocks []planEntry `json:"blocks"`
+	}{entries})
+}
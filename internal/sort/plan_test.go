@@ -0,0 +1,199 @@
+package sort
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+// planMain and planNetwork hold a resource, a variable and two locals blocks
+// that group-by-type moves to other files.
+const planMain = `resource "aws_vpc" "main" {
+  cidr_block = var.cidr
+}
+
+locals {
+  a = 1
+}
+`
+
+const planNetwork = `variable "cidr" {
+  type = string
+}
+
+locals {
+  b = 2
+}
+`
+
+func TestRecordPlan(t *testing.T) {
+	tests := []struct {
+		name   string
+		params Params
+		want   []planEntry
+	}{
+		{
+			name:   "per file",
+			params: Params{},
+			want: []planEntry{
+				{Address: "locals #1", Source: planLocation{"/plan/main.tf", 5, 0}, Destination: &planLocation{"/plan/main.tf", 1, 1}},
+				{Address: "locals #2", Source: planLocation{"/plan/network.tf", 5, 0}, Destination: &planLocation{"/plan/network.tf", 5, 2}},
+				{Address: "resource.aws_vpc.main", Source: planLocation{"/plan/main.tf", 1, 0}, Destination: &planLocation{"/plan/main.tf", 5, 2}},
+				{Address: "variable.cidr", Source: planLocation{"/plan/network.tf", 1, 0}, Destination: &planLocation{"/plan/network.tf", 1, 1}},
+			},
+		},
+		{
+			name:   "group-by-type with merged locals",
+			params: Params{GroupByType: true, MergeBlocks: true, OutputDir: "/out"},
+			want: []planEntry{
+				{Address: "locals #1", Source: planLocation{"/plan/main.tf", 5, 0}, Destination: &planLocation{"/out/locals.tf", 1, 1}},
+				{Address: "locals #2", Source: planLocation{"/plan/network.tf", 5, 0}, Destination: &planLocation{"/out/locals.tf", 1, 1}, Merged: true},
+				{Address: "resource.aws_vpc.main", Source: planLocation{"/plan/main.tf", 1, 0}, Destination: &planLocation{"/out/main.tf", 1, 1}},
+				{Address: "variable.cidr", Source: planLocation{"/plan/network.tf", 1, 0}, Destination: &planLocation{"/out/variables.tf", 1, 1}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			memFS := afero.NewMemMapFs()
+			_ = memFS.MkdirAll("/plan", 0755)
+			_ = afero.WriteFile(memFS, "/plan/main.tf", []byte(planMain), 0644)
+			_ = afero.WriteFile(memFS, "/plan/network.tf", []byte(planNetwork), 0644)
+			s := NewSorter(&params, memFS)
+			s.plan = &runPlan{}
+			files := []string{"/plan/main.tf", "/plan/network.tf"}
+			sortedFiles, err := s.sortFiles(files)
+			if err != nil {
+				t.Fatalf("sortFiles() error: %v", err)
+			}
+			if err := s.recordPlan("/plan", files, sortedFiles); err != nil {
+				t.Fatalf("recordPlan() error: %v", err)
+			}
+			got := s.plan.entries
+			gotByAddress := map[string]planEntry{}
+			for _, e := range got {
+				gotByAddress[e.Address] = e
+			}
+			for _, want := range tt.want {
+				if !reflect.DeepEqual(gotByAddress[want.Address], want) {
+					t.Errorf("entry %s = %+v (destination %+v), want %+v (destination %+v)",
+						want.Address, gotByAddress[want.Address], gotByAddress[want.Address].Destination, want, want.Destination)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("got %d entries, want %d", len(got), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestRecordPlanJSON(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/plan", 0755)
+	_ = afero.WriteFile(memFS, "/plan/main.tf.json", []byte(`{
+  "variable": {"region": {}},
+  "resource": {"aws_vpc": {"main": {"cidr_block": "10.0.0.0/16"}}}
+}
+`), 0644)
+	s := NewSorter(&Params{}, memFS)
+	s.plan = &runPlan{}
+	files := []string{"/plan/main.tf.json"}
+	sortedFiles, err := s.sortFiles(files)
+	if err != nil {
+		t.Fatalf("sortFiles() error: %v", err)
+	}
+	if err := s.recordPlan("/plan", files, sortedFiles); err != nil {
+		t.Fatalf("recordPlan() error: %v", err)
+	}
+
+	gotByAddress := map[string]planEntry{}
+	for _, e := range s.plan.entries {
+		gotByAddress[e.Address] = e
+	}
+	want := []planEntry{
+		{Address: "resource.aws_vpc.main", Source: planLocation{"/plan/main.tf.json", 3, 0}, Destination: &planLocation{"/plan/main.tf.json", 7, 2}},
+		{Address: "variable.region", Source: planLocation{"/plan/main.tf.json", 2, 0}, Destination: &planLocation{"/plan/main.tf.json", 3, 1}},
+	}
+	for _, w := range want {
+		if !reflect.DeepEqual(gotByAddress[w.Address], w) {
+			t.Errorf("entry %s = %+v (destination %+v), want %+v (destination %+v)",
+				w.Address, gotByAddress[w.Address], gotByAddress[w.Address].Destination, w, w.Destination)
+		}
+	}
+	if len(s.plan.entries) != len(want) {
+		t.Errorf("got %d entries, want %d", len(s.plan.entries), len(want))
+	}
+}
+
+func TestWriteTextPlan(t *testing.T) {
+	entries := []planEntry{
+		{Address: "variable.cidr", Source: planLocation{File: "network.tf", Line: 1}, Destination: &planLocation{File: "variables.tf", Line: 1, Position: 1}},
+		{Address: "locals #2", Source: planLocation{File: "network.tf", Line: 5}, Destination: &planLocation{File: "locals.tf", Line: 1, Position: 1}, Merged: true},
+	}
+	var buf strings.Builder
+	if err := writeTextPlan(&buf, entries); err != nil {
+		t.Fatal(err)
+	}
+	want := "variable.cidr  network.tf:1  -> variables.tf:1\n" +
+		"locals #2      network.tf:5  -> locals.tf:1 (merged)\n"
+	if buf.String() != want {
+		t.Errorf("writeTextPlan() =\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestManifest(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/plan", 0755)
+	_ = afero.WriteFile(memFS, "/plan/main.tf", []byte(planMain), 0644)
+	_ = afero.WriteFile(memFS, "/plan/network.tf", []byte(planNetwork), 0644)
+	if err := NewSorter(&Params{GroupByType: true, OutputDir: "/out", Manifest: true}, memFS).run("/plan"); err != nil {
+		t.Fatalf("run() error: %v", err)
+	}
+
+	content, err := afero.ReadFile(memFS, "/out/"+manifestFileName)
+	if err != nil {
+		t.Fatalf("manifest not written: %v", err)
+	}
+	var manifest struct {
+		Blocks []planEntry `json:"blocks"`
+	}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, content)
+	}
+	if len(manifest.Blocks) != 4 || manifest.Blocks[0].Address != "resource.aws_vpc.main" {
+		t.Errorf("expected 4 blocks in source order, got:\n%s", content)
+	}
+}
+
+func TestPlanFlagConflicts(t *testing.T) {
+	tests := []struct {
+		name   string
+		params Params
+		want   string
+	}{
+		{name: "unknown format", params: Params{Plan: "yaml"}, want: `unknown plan format "yaml"`},
+		{name: "plan with diff", params: Params{Plan: planText, Diff: true}, want: "the plan flag conflicts with the diff and summary flags"},
+		{name: "plan with summary", params: Params{Plan: planJSON, Summary: true}, want: "the plan flag conflicts with the diff and summary flags"},
+		{name: "manifest without output-dir", params: Params{Manifest: true, Inline: true}, want: "the manifest flag requires the output-dir flag"},
+		{name: "json plan with json report on stdout", params: Params{Plan: planJSON, ReportFormat: reportJSON, Inline: true}, want: "needs report-file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			memFS := afero.NewMemMapFs()
+			_ = memFS.MkdirAll("/plan", 0755)
+			_ = afero.WriteFile(memFS, "/plan/main.tf", []byte(planMain), 0644)
+			_ = afero.WriteFile(memFS, "/plan/network.tf", []byte(planNetwork), 0644)
+			err := NewSorter(&params, memFS).run("/plan")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
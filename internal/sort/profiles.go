@@ -0,0 +1,104 @@
+package sort
+
+import (
+	"fmt"
+	gosort "sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	hclsyntax "github.com/hashicorp/hcl/v2/hclsyntax"
+	log "github.com/sirupsen/logrus"
+)
+
+// ArgumentProfile orders the normal (non-meta) arguments of a resource or
+// data source type. Arguments and nested blocks named in First are placed at
+// the start of the normal group, those in Last at its end, both in the listed
+// order. Everything else stays alphabetical in between.
+type ArgumentProfile struct {
+	First []string `yaml:"first"`
+	Last  []string `yaml:"last"`
+}
+
+// findArgumentProfile returns the profile for a resource or data source type.
+// An exact key wins over glob patterns; among matching patterns the longest
+// (most specific) one is used, with ties broken alphabetically.
+func findArgumentProfile(profiles map[string]ArgumentProfile, resourceType string) (ArgumentProfile, bool) {
+	if len(profiles) == 0 {
+		return ArgumentProfile{}, false
+	}
+	if profile, ok := profiles[resourceType]; ok {
+		return profile, true
+	}
+
+	best := ""
+	for pattern := range profiles {
+		if matched, err := doublestar.Match(pattern, resourceType); err != nil || !matched {
+			continue
+		}
+		if best == "" || len(pattern) > len(best) || (len(pattern) == len(best) && pattern < best) {
+			best = pattern
+		}
+	}
+	if best == "" {
+		return ArgumentProfile{}, false
+	}
+	return profiles[best], true
+}
+
+// getArgumentProfile returns the configured profile for block, if any.
+// Profiles only apply to resource and data blocks.
+func (s *Sorter) getArgumentProfile(block *hclsyntax.Block) (ArgumentProfile, bool) {
+	if (block.Type != "resource" && block.Type != "data") || len(block.Labels) == 0 {
+		return ArgumentProfile{}, false
+	}
+	return findArgumentProfile(s.params.ArgumentProfiles, block.Labels[0])
+}
+
+// applyArgumentProfile reorders alphabetically sorted keys so that the
+// profile's First keys lead and its Last keys trail. Keys are matched by
+// argument name or nested block type; keyName maps a dynamic block key to
+// the block type it generates when dynamic blocks sort with static ones.
+func applyArgumentProfile(keys []string, profile ArgumentProfile, keyName func(string) (string, bool)) {
+	log.WithFields(log.Fields{"keys": keys, "profile": profile}).Traceln("Starting applyArgumentProfile")
+
+	rank := func(key string) int {
+		name, _ := keyName(key)
+		name = strings.SplitN(name, " ", 2)[0]
+		for i, arg := range profile.First {
+			if arg == name {
+				return i
+			}
+		}
+		for i, arg := range profile.Last {
+			if arg == name {
+				return len(profile.First) + 1 + i
+			}
+		}
+		return len(profile.First)
+	}
+
+	gosort.SliceStable(keys, func(i, j int) bool {
+		return rank(keys[i]) < rank(keys[j])
+	})
+}
+
+// validateArgumentProfiles checks the argument-profiles setting.
+func validateArgumentProfiles(profiles map[string]ArgumentProfile) error {
+	for pattern, profile := range profiles {
+		if pattern == "" || !doublestar.ValidatePattern(pattern) {
+			return fmt.Errorf("argument-profiles: invalid type pattern %q", pattern)
+		}
+
+		seen := make(map[string]bool)
+		for _, arg := range append(append([]string{}, profile.First...), profile.Last...) {
+			if !hclsyntax.ValidIdentifier(arg) {
+				return fmt.Errorf("argument-profiles[%s]: %q is not a valid argument name", pattern, arg)
+			}
+			if seen[arg] {
+				return fmt.Errorf("argument-profiles[%s]: %q is listed more than once", pattern, arg)
+			}
+			seen[arg] = true
+		}
+	}
+	return nil
+}
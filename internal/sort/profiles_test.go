@@ -0,0 +1,121 @@
+package sort
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindArgumentProfile(t *testing.T) {
+	profiles := map[string]ArgumentProfile{
+		"aws_security_group": {First: []string{"name", "description"}},
+		"aws_s3_*":           {First: []string{"bucket"}},
+		"aws_*":              {Last: []string{"tags"}},
+		"google_*":           {First: []string{"project"}},
+	}
+
+	tests := []struct {
+		resourceType string
+		want         ArgumentProfile
+		wantOK       bool
+	}{
+		{"aws_security_group", profiles["aws_security_group"], true},
+		{"aws_s3_bucket", profiles["aws_s3_*"], true},
+		{"aws_instance", profiles["aws_*"], true},
+		{"google_compute_network", profiles["google_*"], true},
+		{"azurerm_resource_group", ArgumentProfile{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.resourceType, func(t *testing.T) {
+			got, ok := findArgumentProfile(profiles, tt.resourceType)
+			if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findArgumentProfile(%q) = %+v, %v; want %+v, %v", tt.resourceType, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestApplyArgumentProfile(t *testing.T) {
+	keys := []string{"description", "egress", "ingress", "name", "tags", "vpc_id"}
+	applyArgumentProfile(keys, ArgumentProfile{
+		First: []string{"name", "description"},
+		Last:  []string{"ingress", "egress", "tags"},
+	}, func(key string) (string, bool) { return key, false })
+
+	want := []string{"name", "description", "vpc_id", "ingress", "egress", "tags"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("got %v, want %v", keys, want)
+	}
+}
+
+func TestValidateArgumentProfiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		profiles map[string]ArgumentProfile
+		wantErr  string
+	}{
+		{name: "valid", profiles: map[string]ArgumentProfile{"google_*": {First: []string{"project"}}}},
+		{name: "bad pattern", profiles: map[string]ArgumentProfile{"[google": {}}, wantErr: "invalid type pattern"},
+		{name: "bad argument", profiles: map[string]ArgumentProfile{"aws_*": {First: []string{"1st"}}}, wantErr: "not a valid argument name"},
+		{name: "duplicate argument", profiles: map[string]ArgumentProfile{"aws_*": {First: []string{"tags"}, Last: []string{"tags"}}}, wantErr: "more than once"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateArgumentProfiles(tt.profiles)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestSortBytesArgumentProfiles verifies that profiles only reorder the
+// normal arguments of matching resource and data blocks.
+func TestSortBytesArgumentProfiles(t *testing.T) {
+	input := []byte(`resource "aws_security_group" "web" {
+  vpc_id      = "vpc-123"
+  tags        = {}
+  name        = "web"
+  description = "Web servers"
+  count       = 1
+}
+
+resource "aws_instance" "web" {
+  name = "web"
+  ami  = "ami-123"
+}
+`)
+	want := `resource "aws_instance" "web" {
+  ami  = "ami-123"
+  name = "web"
+}
+
+resource "aws_security_group" "web" {
+  count = 1
+
+  name        = "web"
+  description = "Web servers"
+  vpc_id      = "vpc-123"
+  tags        = {}
+}
+`
+	result, err := SortBytes(input, "main.tf", &Params{
+		ArgumentProfiles: map[string]ArgumentProfile{
+			"aws_security_group": {First: []string{"name", "description"}, Last: []string{"tags"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SortBytes returned unexpected error: %v", err)
+	}
+	if string(result) != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", result, want)
+	}
+}
@@ -18,8 +18,29 @@ type Params struct {
 	Excludes []string `yaml:"exclude"`
 	// If the group-by-type flag is set, the resources will be grouped by type in the output files.
 	// Otherwise, the resources will be sorted alphabetically ascending by resource type and name in the existing files.
-	// Conflicts with the inline flag.
+	// With the inline flag, the grouped files replace the files of the target
+	// directory and files whose blocks all moved are deleted.
 	GroupByType bool `yaml:"group-by-type"`
+	// FileGroups overrides or extends the block type to file name mapping used
+	// by group-by-type (e.g. provider: providers.tf). The "default" key
+	// replaces the main.tf fallback for block types that are not mapped.
+	FileGroups map[string]string `yaml:"file-groups"`
+	// FileRules is an ordered list of routing rules applied by group-by-type
+	// before FileGroups. The first rule that matches a block decides its
+	// output file; unmatched blocks fall back to FileGroups. Config file only.
+	FileRules []FileRule `yaml:"file-rules"`
+	// If MergeBlocks is set, the locals blocks and the terraform blocks
+	// written to the same output file are each folded into one block, and
+	// the required_providers blocks inside the merged terraform block are
+	// folded too. An argument defined twice with different values is an
+	// error.
+	MergeBlocks bool `yaml:"merge-blocks"`
+	// DuplicateBlocks selects what happens when two blocks share an address
+	// (type and labels, plus alias for providers), such as two
+	// variable "region" blocks in different files: "warn" (the default)
+	// logs both file:line locations, "error" stops the run and "off" skips
+	// the check.
+	DuplicateBlocks string `yaml:"duplicate-blocks"`
 	// If the has-header flag is set, the input files have a header.
 	HasHeader bool `yaml:"has-header"`
 	// If the header-pattern flag is set, the header pattern will be used to find the header in the input files.
@@ -30,7 +51,7 @@ type Params struct {
 	// as the file header.
 	HeaderEndPattern string `yaml:"header-end-pattern"`
 	// If the inline flag is set, the resources will be sorted in place in the input files.
-	// Conflicts with the group-by-type and output-dir flags.
+	// Conflicts with the output-dir flag. With group-by-type the target must be a directory.
 	Inline bool `yaml:"inline"`
 	// If the keep-header flag is set, the header matched in the header pattern will be persisted in the output files.
 	KeepHeader bool `yaml:"keep-header"`
@@ -43,11 +64,109 @@ type Params struct {
 	// If the diff flag is set, a unified diff of changes is printed to stdout
 	// instead of writing files.
 	Diff bool `yaml:"diff"`
+	// If Summary is set, a block-level summary of the changes is printed
+	// instead of the sorted files: blocks moved within or between files,
+	// bodies whose arguments were reordered and comments removed, followed
+	// by one stat line per changed file. Files are still written with
+	// inline or output-dir. Conflicts with the diff flag.
+	Summary bool `yaml:"summary"`
+	// Color selects whether diffs are colored: "auto" (the default) colors
+	// them when stdout is a terminal and NO_COLOR is empty or unset,
+	// "always" and "never" force it on or off. Colored diffs highlight the
+	// changed words of changed lines. Plain output is unchanged.
+	Color string `yaml:"color"`
+	// Plan selects a move plan printed instead of the sorted files: "text"
+	// or "json". The plan lists every top-level block with its source file
+	// and line and its destination file, line and position. Files are still
+	// written with inline or output-dir. Conflicts with the diff and summary
+	// flags.
+	Plan string `yaml:"plan"`
+	// If Manifest is set, the move plan is written as JSON to
+	// tforganize-manifest.json in the output directory. Requires the
+	// output-dir flag.
+	Manifest bool `yaml:"manifest"`
+	// If ColorMoved is set, colored diffs dim runs of lines that were only
+	// moved, so that real changes stand out.
+	ColorMoved bool `yaml:"color-moved"`
+	// ReportFormat selects the format of the per-file run report: "text"
+	// (the default), "json", "sarif", "junit", "checkstyle", "github" or
+	// "gitlab-codequality". Each file is reported as unchanged, changed or
+	// created, with the changed line ranges of the original file.
+	ReportFormat string `yaml:"report-format"`
+	// ReportFile is the path the report is written to. When empty, reports
+	// other than text are printed to stdout, which requires a run that does
+	// not print sorted files or diffs there.
+	ReportFile string `yaml:"report-file"`
 	// If NoSortByType is set, blocks are sorted alphabetically by type name
 	// instead of using the logical type priority ordering (terraform → variable
 	// → locals → data → resource → module → import → moved → removed → check
 	// → output).
 	NoSortByType bool `yaml:"no-sort-by-type"`
+	// Collation selects how block labels and nested block keys are compared:
+	// "byte" (the default), "natural" (numeric-aware, so subnet_2 sorts before
+	// subnet_10) or "case-insensitive".
+	Collation string `yaml:"collation"`
+	// DynamicBlocks controls where dynamic blocks sort among nested blocks:
+	// "separate" (the default) sorts them under "dynamic", while "static-first"
+	// and "dynamic-first" sort each dynamic block by its label next to static
+	// blocks of the same type.
+	DynamicBlocks string `yaml:"dynamic-blocks"`
+	// VarsOrder selects how the assignments of variable definitions files
+	// (.tfvars) are ordered: "alphabetical" (the default) or "declaration",
+	// the order of the variable blocks in the same directory.
+	VarsOrder string `yaml:"vars-order"`
+	// MetaArguments adds or overrides the arguments placed first (pre) and
+	// last (post) inside a block type, including nested types such as dynamic
+	// and lifecycle. Configured post lists keep their listed order; built-in
+	// post lists are sorted alphabetically. Config file only.
+	MetaArguments map[string]MetaArgumentList `yaml:"meta-arguments"`
+	// ArgumentProfiles orders the normal arguments of resource and data
+	// blocks, keyed by resource or data source type or a glob such as
+	// "google_*". Config file only.
+	ArgumentProfiles map[string]ArgumentProfile `yaml:"argument-profiles"`
+	// BlockOrder overrides the logical ordering of top-level block types.
+	// Listed types sort first, in the given order; unlisted types follow in
+	// their built-in order. Ignored when NoSortByType is set.
+	BlockOrder []string `yaml:"block-order"`
+	// BlockComparators selects how blocks of the same type are ordered,
+	// keyed by block type. Supported values are "alphabetical" (the default),
+	// "preserve-original" (keep declaration order) and "label:<n>" (compare
+	// the n-th label first, 0-based, then fall back to the full label list).
+	BlockComparators map[string]string `yaml:"block-comparators"`
+	// If OrderByDependencies is set, locals are ordered so that each local is
+	// defined before the locals that reference it, and resource and data
+	// blocks of the same type are ordered so that referenced blocks come
+	// first. Ties are broken alphabetically; cycles fall back to alphabetical
+	// order with a warning.
+	OrderByDependencies bool `yaml:"order-by-dependencies"`
+	// Scope selects what is sorted: "all" (the default) reorders top-level
+	// blocks and sorts their bodies, "blocks" only reorders top-level blocks
+	// and copies bodies verbatim, and "bodies" only sorts bodies and keeps the
+	// top-level block order.
+	Scope string `yaml:"scope"`
+	// BodyDepth limits how deep nested block bodies are sorted. 1 sorts only
+	// the bodies of top-level blocks; deeper blocks are copied verbatim.
+	// 0 (the default) means no limit.
+	BodyDepth int `yaml:"body-depth"`
+	// If SortObjectKeys is set, the keys of object literals (e.g. tags = {...}
+	// or type = object({...})) in the arguments listed in ObjectKeyArguments
+	// are sorted alphabetically, and provider requirements inside
+	// required_providers are ordered source, version, then the rest.
+	SortObjectKeys bool `yaml:"sort-object-keys"`
+	// ObjectKeyArguments lists the argument names whose object values are
+	// sorted by SortObjectKeys. Defaults to labels, tags and type.
+	ObjectKeyArguments []string `yaml:"object-key-arguments"`
+	// If SortLists is set, the elements of list literals at the argument
+	// paths in ListPaths are sorted (module providers maps by key), as is
+	// the list passed to toset(). Lists holding computed expressions, or
+	// comments that cannot stay attached to an element, are left alone.
+	SortLists bool `yaml:"sort-lists"`
+	// ListPaths lists the argument paths sorted by SortLists. A path is the
+	// top-level block type and first label, any nested block types and the
+	// argument name, joined by dots; each segment may be a glob (e.g.
+	// "resource.*.lifecycle.ignore_changes"). Defaults to the Terraform
+	// arguments whose order does not matter.
+	ListPaths []string `yaml:"list-paths"`
 	// If the remove-comments flag is set, the comments will be removed from the files.
 	// Otherwise, the comments will be preserved.
 	RemoveComments bool `yaml:"remove-comments"`
@@ -74,6 +193,14 @@ func Sort(target string, settings *Params) error {
 // The filename parameter is used for error messages and HCL diagnostics.
 func SortBytes(content []byte, filename string, settings *Params) ([]byte, error) {
 	s := NewSorter(settings, afero.NewMemMapFs())
+	if err := s.validateRules(); err != nil {
+		return nil, err
+	}
+	if body, err := s.parseHclBytes(content, filename); err == nil {
+		if err := s.checkDuplicateBlocks(nativeBlocks(body)); err != nil {
+			return nil, err
+		}
+	}
 	results, err := s.sortFileBytes(content, filename)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,393 @@
+package sort
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	gosort "sort"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	hclsyntax "github.com/hashicorp/hcl/v2/hclsyntax"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// RenameResult describes what Rename changed.
+type RenameResult struct {
+	// References is the number of references rewritten, not counting the
+	// declaring block.
+	References int
+	// Files lists the files that were rewritten, in name order.
+	Files []string
+}
+
+// movableAddress is a resource address (type.name) or a module call address
+// (module.name), the two kinds of address a moved block accepts.
+type movableAddress struct {
+	module     bool
+	typeName   string // resource type; empty for modules
+	name       string
+	rawAddress string
+}
+
+// parseMovableAddress parses a resource or module call address.
+func parseMovableAddress(address string) (movableAddress, error) {
+	parts := strings.Split(address, ".")
+	if len(parts) != 2 || !hclsyntax.ValidIdentifier(parts[0]) || !hclsyntax.ValidIdentifier(parts[1]) || parts[0] == "data" {
+		return movableAddress{}, fmt.Errorf("invalid address %q: expected <resource type>.<name> or module.<name>", address)
+	}
+	if parts[0] == "module" {
+		return movableAddress{module: true, name: parts[1], rawAddress: address}, nil
+	}
+	return movableAddress{typeName: parts[0], name: parts[1], rawAddress: address}, nil
+}
+
+// String returns the address as written in references.
+func (a movableAddress) String() string {
+	return a.rawAddress
+}
+
+// declares reports whether block declares the address.
+func (a movableAddress) declares(block *hclsyntax.Block) bool {
+	if a.module {
+		return block.Type == "module" && len(block.Labels) == 1 && block.Labels[0] == a.name
+	}
+	return block.Type == "resource" && len(block.Labels) == 2 && block.Labels[0] == a.typeName && block.Labels[1] == a.name
+}
+
+// referencedBy reports whether traversal starts with the address.
+func (a movableAddress) referencedBy(traversal hcl.Traversal) bool {
+	if len(traversal) < 2 || traversal.RootName() != strings.SplitN(a.rawAddress, ".", 2)[0] {
+		return false
+	}
+	attr, ok := traversal[1].(hcl.TraverseAttr)
+	return ok && attr.Name == a.name
+}
+
+// textEdit replaces src[start:end] with text.
+type textEdit struct {
+	start, end int
+	text       string
+}
+
+// applyEdits applies non-overlapping edits to src.
+func applyEdits(src []byte, edits []textEdit) []byte {
+	gosort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	out := append([]byte(nil), src...)
+	for _, e := range edits {
+		out = append(out[:e.start], append([]byte(e.text), out[e.end:]...)...)
+	}
+	return out
+}
+
+// Rename renames the resource or module call from to to in the module in
+// dir: it rewrites the labels of the declaring block and every reference
+// to it, appends a moved block recording the rename, and sorts the files it
+// changed with settings.
+func Rename(from, to, dir string, settings *Params) (*RenameResult, error) {
+	s := NewSorter(settings, afero.NewOsFs())
+	return s.rename(from, to, dir)
+}
+
+// rename is the internal entry point for a rename.
+func (s *Sorter) rename(from, to, dir string) (*RenameResult, error) {
+	log.WithFields(log.Fields{"from": from, "to": to, "dir": dir}).Traceln("Starting rename")
+
+	if err := s.validateRules(); err != nil {
+		return nil, err
+	}
+	fromAddress, err := parseMovableAddress(from)
+	if err != nil {
+		return nil, err
+	}
+	toAddress, err := parseMovableAddress(to)
+	if err != nil {
+		return nil, err
+	}
+	if fromAddress.module != toAddress.module {
+		return nil, fmt.Errorf("cannot rename %s to %s: both addresses must be resources or both module calls", from, to)
+	}
+	if fromAddress.typeName != toAddress.typeName {
+		return nil, fmt.Errorf("cannot rename %s to %s: the resource type must not change", from, to)
+	}
+	if fromAddress.name == toAddress.name {
+		return nil, fmt.Errorf("cannot rename %s to itself", from)
+	}
+
+	files, err := s.getFilesInFolder(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not get files in %s: %w", dir, err)
+	}
+
+	sources := map[string][]byte{}
+	edits := map[string][]textEdit{}
+	jsonFiles := map[string]*jsonValue{}
+	var declaringFile string
+	result := &RenameResult{}
+	for _, f := range files {
+		// Variable definitions files cannot refer to resources or modules.
+		if isVarsFile(f) {
+			continue
+		}
+		src, err := s.afs.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s: %w", f, err)
+		}
+
+		if isJSONFile(f) {
+			root, err := parseJSON(src, f)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse %s: %w", f, err)
+			}
+			declares, references, err := renameJSON(root, f, fromAddress, toAddress)
+			if err != nil {
+				return nil, err
+			}
+			if declares {
+				if declaringFile != "" {
+					return nil, fmt.Errorf("cannot rename %s: it is declared more than once", from)
+				}
+				declaringFile = f
+			}
+			if declares || references > 0 {
+				jsonFiles[f] = root
+			}
+			result.References += references
+			continue
+		}
+
+		body, err := s.parseHclBytes(src, f)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %s: %w", f, err)
+		}
+		sources[f] = src
+
+		for _, block := range body.Blocks {
+			if toAddress.declares(block) {
+				return nil, fmt.Errorf("cannot rename %s: %s is already declared at %s:%d", from, to, f, block.TypeRange.Start.Line)
+			}
+			if fromAddress.declares(block) {
+				if declaringFile != "" {
+					return nil, fmt.Errorf("cannot rename %s: it is declared more than once", from)
+				}
+				declaringFile = f
+				nameRange := block.LabelRanges[len(block.LabelRanges)-1]
+				edits[f] = append(edits[f], textEdit{nameRange.Start.Byte, nameRange.End.Byte, fmt.Sprintf("%q", toAddress.name)})
+			}
+
+			// Moved blocks keep recording the history of the old address.
+			if block.Type == "moved" {
+				continue
+			}
+			hclsyntax.VisitAll(block, func(node hclsyntax.Node) hcl.Diagnostics {
+				expr, ok := node.(*hclsyntax.ScopeTraversalExpr)
+				if !ok || !fromAddress.referencedBy(expr.Traversal) {
+					return nil
+				}
+				start := expr.Traversal[0].SourceRange().Start.Byte
+				end := expr.Traversal[1].SourceRange().End.Byte
+				edits[f] = append(edits[f], textEdit{start, end, toAddress.String()})
+				result.References++
+				return nil
+			})
+		}
+	}
+	if declaringFile == "" {
+		return nil, fmt.Errorf("cannot rename %s: it is not declared in %s", from, dir)
+	}
+
+	// Rewrite the files in memory, adding the moved block next to the
+	// declaration.
+	rewritten := map[string][]byte{}
+	for f, fileEdits := range edits {
+		src := applyEdits(sources[f], fileEdits)
+		if f == declaringFile {
+			if len(src) > 0 && src[len(src)-1] != '\n' {
+				src = append(src, '\n')
+			}
+			src = append(src, fmt.Sprintf("\nmoved {\n  from = %s\n  to   = %s\n}\n", fromAddress, toAddress)...)
+		}
+		rewritten[f] = src
+	}
+	for f, root := range jsonFiles {
+		if f == declaringFile {
+			addJSONMovedBlock(root, fromAddress, toAddress)
+		}
+		var buf bytes.Buffer
+		writeJSONValue(&buf, root, "")
+		buf.WriteByte('\n')
+		rewritten[f] = buf.Bytes()
+	}
+	for f := range rewritten {
+		result.Files = append(result.Files, f)
+	}
+	gosort.Strings(result.Files)
+
+	// Sort the rewritten files on a copy-on-write layer over the module,
+	// so that nothing is written unless every file sorts. Blocks are not
+	// moved between files, so group-by-type does not apply.
+	staged := afero.NewCopyOnWriteFs(afero.NewReadOnlyFs(s.fs), afero.NewMemMapFs())
+	sortParams := *s.params
+	sortParams.GroupByType = false
+	sorter := NewSorter(&sortParams, staged)
+	for f, src := range rewritten {
+		if err := sorter.writeFile(f, src); err != nil {
+			return nil, fmt.Errorf("could not stage %s: %w", f, err)
+		}
+	}
+	sortedFiles, err := sorter.sortFiles(result.Files)
+	if err != nil {
+		return nil, fmt.Errorf("could not sort renamed files: %w", err)
+	}
+	for name, content := range sortedFiles {
+		rewritten[filepath.Join(dir, name)] = content
+	}
+
+	for _, f := range result.Files {
+		if err := s.writeFile(f, rewritten[f]); err != nil {
+			return nil, fmt.Errorf("could not write %s: %w", f, err)
+		}
+	}
+
+	return result, nil
+}
+
+// jsonReferenceArguments are the arguments whose JSON string values are
+// bare references rather than string templates.
+var jsonReferenceArguments = []string{"depends_on", "replace_triggered_by"}
+
+// renameJSON applies a rename to the Terraform JSON file f parsed as root.
+// It renames the declaring block, if f has it, and rewrites the references
+// in the file's string values. It returns whether f declares from and the
+// number of references rewritten.
+func renameJSON(root *jsonValue, f string, from, to movableAddress) (bool, int, error) {
+	declares := false
+	references := 0
+	for _, m := range root.members {
+		// Moved blocks keep recording the history of the old address.
+		if m.key == "moved" {
+			continue
+		}
+		if len(jsonDeclarations(m, to)) > 0 {
+			return false, 0, fmt.Errorf("cannot rename %s: %s is already declared in %s", from, to, f)
+		}
+		for _, declaration := range jsonDeclarations(m, from) {
+			if declares {
+				return false, 0, fmt.Errorf("cannot rename %s: it is declared more than once", from)
+			}
+			declares = true
+			declaration.key = to.name
+		}
+		references += renameJSONReferences(m.value, m.key, from, to)
+	}
+	return declares, references, nil
+}
+
+// jsonDeclarations returns the members of the top-level member m that
+// declare address: the name properties of a "resource" or "module" object.
+func jsonDeclarations(m jsonMember, address movableAddress) []*jsonMember {
+	if m.value.kind != jsonObject {
+		return nil
+	}
+	names := m.value
+	switch {
+	case address.module && m.key == "module":
+	case !address.module && m.key == "resource":
+		names = nil
+		for i := range m.value.members {
+			if typ := m.value.members[i]; typ.key == address.typeName && typ.value.kind == jsonObject {
+				names = typ.value
+			}
+		}
+		if names == nil {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	var declarations []*jsonMember
+	for i := range names.members {
+		if names.members[i].key == address.name {
+			declarations = append(declarations, &names.members[i])
+		}
+	}
+	return declarations
+}
+
+// renameJSONReferences rewrites the references to from in the string values
+// inside value, the value of the property key, and returns their number.
+func renameJSONReferences(value *jsonValue, key string, from, to movableAddress) int {
+	references := 0
+	switch value.kind {
+	case jsonObject:
+		for _, m := range value.members {
+			references += renameJSONReferences(m.value, m.key, from, to)
+		}
+	case jsonArray:
+		for _, item := range value.items {
+			references += renameJSONReferences(item, key, from, to)
+		}
+	default:
+		var str string
+		if err := json.Unmarshal([]byte(value.literal), &str); err != nil {
+			return 0
+		}
+		var expr hclsyntax.Expression
+		var diags hcl.Diagnostics
+		switch {
+		case stringExists(jsonReferenceArguments, key):
+			expr, diags = hclsyntax.ParseExpression([]byte(str), "", hcl.InitialPos)
+		case strings.Contains(str, "${") || strings.Contains(str, "%{"):
+			expr, diags = hclsyntax.ParseTemplate([]byte(str), "", hcl.InitialPos)
+		default:
+			return 0
+		}
+		if diags.HasErrors() {
+			return 0
+		}
+
+		var edits []textEdit
+		hclsyntax.VisitAll(expr, func(node hclsyntax.Node) hcl.Diagnostics {
+			traversal, ok := node.(*hclsyntax.ScopeTraversalExpr)
+			if !ok || !from.referencedBy(traversal.Traversal) {
+				return nil
+			}
+			start := traversal.Traversal[0].SourceRange().Start.Byte
+			end := traversal.Traversal[1].SourceRange().End.Byte
+			edits = append(edits, textEdit{start, end, to.String()})
+			return nil
+		})
+		if len(edits) > 0 {
+			value.literal = quoteJSON(string(applyEdits([]byte(str), edits)))
+		}
+		references += len(edits)
+	}
+	return references
+}
+
+// addJSONMovedBlock records the rename in a moved block of root, next to
+// any moved blocks the file already has.
+func addJSONMovedBlock(root *jsonValue, from, to movableAddress) {
+	moved := &jsonValue{kind: jsonObject, members: []jsonMember{
+		{key: "from", value: &jsonValue{literal: quoteJSON(from.String())}},
+		{key: "to", value: &jsonValue{literal: quoteJSON(to.String())}},
+	}}
+	for i, m := range root.members {
+		if m.key != "moved" {
+			continue
+		}
+		switch m.value.kind {
+		case jsonArray:
+			m.value.items = append(m.value.items, moved)
+		case jsonObject:
+			root.members[i].value = &jsonValue{kind: jsonArray, items: []*jsonValue{m.value, moved}}
+		default:
+			continue
+		}
+		return
+	}
+	root.members = append(root.members, jsonMember{key: "moved", value: moved})
+}
@@ -0,0 +1,269 @@
+package sort
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestParseMovableAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    movableAddress
+		wantErr bool
+	}{
+		{address: "aws_iam_role.app", want: movableAddress{typeName: "aws_iam_role", name: "app", rawAddress: "aws_iam_role.app"}},
+		{address: "module.network", want: movableAddress{module: true, name: "network", rawAddress: "module.network"}},
+		{address: "data.aws_ami.ubuntu", wantErr: true},
+		{address: "data.x", wantErr: true},
+		{address: "aws_iam_role", wantErr: true},
+		{address: "aws_iam_role.app[0]", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			got, err := parseMovableAddress(tt.address)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("parseMovableAddress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseMovableAddress() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// renameMain and renameOutputs reference aws_iam_role.old and module.net
+// from two files.
+const renameMain = `resource "aws_iam_role" "old" {
+  name = "app"
+}
+
+resource "aws_iam_role_policy" "p" {
+  role       = aws_iam_role.old.id
+  name       = "${aws_iam_role.old.name}-policy"
+  depends_on = [aws_iam_role.old]
+}
+
+resource "aws_iam_role" "oldest" {
+  name = aws_iam_role.old_unrelated.name
+}
+
+moved {
+  from = aws_iam_role.older
+  to   = aws_iam_role.old
+}
+
+module "net" {
+  source = "./net"
+}
+`
+
+const renameOutputs = `output "role" {
+  value = aws_iam_role.old.arn
+}
+
+output "vpc" {
+  value = module.net.vpc_id
+}
+`
+
+func TestRenameResource(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/rn", 0755)
+	_ = afero.WriteFile(memFS, "/rn/main.tf", []byte(renameMain), 0644)
+	_ = afero.WriteFile(memFS, "/rn/outputs.tf", []byte(renameOutputs), 0644)
+	result, err := NewSorter(&Params{}, memFS).rename("aws_iam_role.old", "aws_iam_role.app", "/rn")
+	if err != nil {
+		t.Fatalf("rename() error: %v", err)
+	}
+	if result.References != 4 || !reflect.DeepEqual(result.Files, []string{"/rn/main.tf", "/rn/outputs.tf"}) {
+		t.Errorf("rename() = %+v, want 4 references in main.tf and outputs.tf", result)
+	}
+
+	main, _ := afero.ReadFile(memFS, "/rn/main.tf")
+	want := `resource "aws_iam_role" "app" {
+  name = "app"
+}
+
+resource "aws_iam_role" "oldest" {
+  name = aws_iam_role.old_unrelated.name
+}
+
+resource "aws_iam_role_policy" "p" {
+  name = "${aws_iam_role.app.name}-policy"
+  role = aws_iam_role.app.id
+
+  depends_on = [aws_iam_role.app]
+}
+
+module "net" {
+  source = "./net"
+}
+
+moved {
+  from = aws_iam_role.older
+  to   = aws_iam_role.old
+}
+
+moved {
+  from = aws_iam_role.old
+  to   = aws_iam_role.app
+}
+`
+	if string(main) != want {
+		t.Errorf("main.tf =\n%s\nwant:\n%s", main, want)
+	}
+	outputs, _ := afero.ReadFile(memFS, "/rn/outputs.tf")
+	if !strings.Contains(string(outputs), "value = aws_iam_role.app.arn") {
+		t.Errorf("expected outputs.tf reference to be renamed, got:\n%s", outputs)
+	}
+}
+
+func TestRenameModule(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/rn", 0755)
+	_ = afero.WriteFile(memFS, "/rn/main.tf", []byte(renameMain), 0644)
+	_ = afero.WriteFile(memFS, "/rn/outputs.tf", []byte(renameOutputs), 0644)
+	result, err := NewSorter(&Params{}, memFS).rename("module.net", "module.network", "/rn")
+	if err != nil {
+		t.Fatalf("rename() error: %v", err)
+	}
+	if result.References != 1 {
+		t.Errorf("expected 1 reference, got %d", result.References)
+	}
+	main, _ := afero.ReadFile(memFS, "/rn/main.tf")
+	for _, want := range []string{`module "network" {`, "moved {\n  from = module.net\n  to   = module.network\n}\n"} {
+		if !strings.Contains(string(main), want) {
+			t.Errorf("expected main.tf to contain %q, got:\n%s", want, main)
+		}
+	}
+	outputs, _ := afero.ReadFile(memFS, "/rn/outputs.tf")
+	if !strings.Contains(string(outputs), "value = module.network.vpc_id") {
+		t.Errorf("expected module output reference to be renamed, got:\n%s", outputs)
+	}
+}
+
+func TestRenameErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		want     string
+	}{
+		{name: "not declared", from: "aws_iam_role.missing", to: "aws_iam_role.app", want: "is not declared in /rn"},
+		{name: "target exists", from: "aws_iam_role.old", to: "aws_iam_role.oldest", want: "aws_iam_role.oldest is already declared at /rn/main.tf:11"},
+		{name: "kind changes", from: "module.net", to: "aws_iam_role.net", want: "both addresses must be resources or both module calls"},
+		{name: "type changes", from: "aws_iam_role.old", to: "aws_iam_user.old", want: "the resource type must not change"},
+		{name: "same name", from: "aws_iam_role.old", to: "aws_iam_role.old", want: "to itself"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memFS := afero.NewMemMapFs()
+			_ = memFS.MkdirAll("/rn", 0755)
+			_ = afero.WriteFile(memFS, "/rn/main.tf", []byte(renameMain), 0644)
+			_ = afero.WriteFile(memFS, "/rn/outputs.tf", []byte(renameOutputs), 0644)
+			before, _ := afero.ReadFile(memFS, "/rn/main.tf")
+			_, err := NewSorter(&Params{}, memFS).rename(tt.from, tt.to, "/rn")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got: %v", tt.want, err)
+			}
+			after, _ := afero.ReadFile(memFS, "/rn/main.tf")
+			if string(before) != string(after) {
+				t.Error("expected files to be left untouched on error")
+			}
+		})
+	}
+}
+
+// TestRenameSortFailureWritesNothing verifies that a rename whose files do
+// not sort leaves the module unchanged.
+func TestRenameSortFailureWritesNothing(t *testing.T) {
+	const mainTF = "resource \"null_resource\" \"old\" {}\n\noutput \"o\" {\n  value = null_resource.old.id\n}\n\noutput \"o\" {\n  value = 1\n}\n"
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/rn", 0755)
+	_ = afero.WriteFile(memFS, "/rn/main.tf", []byte(mainTF), 0644)
+
+	_, err := NewSorter(&Params{DuplicateBlocks: duplicateBlocksError}, memFS).rename("null_resource.old", "null_resource.new", "/rn")
+	if err == nil || !strings.Contains(err.Error(), "could not sort renamed files") {
+		t.Fatalf("expected a sort error, got: %v", err)
+	}
+	if after, _ := afero.ReadFile(memFS, "/rn/main.tf"); string(after) != mainTF {
+		t.Errorf("expected main.tf to be left untouched, got:\n%s", after)
+	}
+}
+
+func TestRenameWithJSONFiles(t *testing.T) {
+	const mainTF = "resource \"aws_s3_bucket\" \"a\" {\n  bucket = \"a\"\n}\n"
+	const genJSON = `{
+  "resource": {
+    "aws_s3_bucket": {
+      "b": {"bucket": "b"}
+    },
+    "aws_s3_bucket_policy": {
+      "p": {"bucket": "${aws_s3_bucket.a.id}", "depends_on": ["aws_s3_bucket.a"]}
+    }
+  }
+}
+`
+
+	t.Run("rewrites references in JSON files", func(t *testing.T) {
+		memFS := afero.NewMemMapFs()
+		_ = afero.WriteFile(memFS, "/mod/main.tf", []byte(mainTF), 0644)
+		_ = afero.WriteFile(memFS, "/mod/gen.tf.json", []byte(genJSON), 0644)
+
+		result, err := NewSorter(&Params{}, memFS).rename("aws_s3_bucket.a", "aws_s3_bucket.c", "/mod")
+		if err != nil {
+			t.Fatalf("rename() error: %v", err)
+		}
+		if result.References != 2 || !reflect.DeepEqual(result.Files, []string{"/mod/gen.tf.json", "/mod/main.tf"}) {
+			t.Errorf("rename() = %+v, want 2 references in gen.tf.json and main.tf", result)
+		}
+		gen, _ := afero.ReadFile(memFS, "/mod/gen.tf.json")
+		if !strings.Contains(string(gen), `"${aws_s3_bucket.c.id}"`) || !strings.Contains(string(gen), `"aws_s3_bucket.c"`) ||
+			strings.Contains(string(gen), "aws_s3_bucket.a") {
+			t.Errorf("gen.tf.json still refers to aws_s3_bucket.a:\n%s", gen)
+		}
+	})
+
+	t.Run("refuses a name declared in a JSON file", func(t *testing.T) {
+		memFS := afero.NewMemMapFs()
+		_ = afero.WriteFile(memFS, "/mod/main.tf", []byte(mainTF), 0644)
+		_ = afero.WriteFile(memFS, "/mod/gen.tf.json", []byte(genJSON), 0644)
+
+		_, err := NewSorter(&Params{}, memFS).rename("aws_s3_bucket.a", "aws_s3_bucket.b", "/mod")
+		if err == nil || !strings.Contains(err.Error(), "aws_s3_bucket.b is already declared in /mod/gen.tf.json") {
+			t.Errorf("rename() error = %v, want an already declared error", err)
+		}
+		main, _ := afero.ReadFile(memFS, "/mod/main.tf")
+		if string(main) != mainTF {
+			t.Errorf("main.tf was modified:\n%s", main)
+		}
+	})
+
+	t.Run("renames a block declared in a JSON file", func(t *testing.T) {
+		memFS := afero.NewMemMapFs()
+		_ = afero.WriteFile(memFS, "/mod/main.tf", []byte("output \"b\" {\n  value = aws_s3_bucket.b.arn\n}\n"), 0644)
+		_ = afero.WriteFile(memFS, "/mod/gen.tf.json", []byte(genJSON), 0644)
+
+		result, err := NewSorter(&Params{}, memFS).rename("aws_s3_bucket.b", "aws_s3_bucket.d", "/mod")
+		if err != nil {
+			t.Fatalf("rename() error: %v", err)
+		}
+		if result.References != 1 {
+			t.Errorf("rename() = %+v, want 1 reference", result)
+		}
+		gen, _ := afero.ReadFile(memFS, "/mod/gen.tf.json")
+		want := `  "moved": {
+    "from": "aws_s3_bucket.b",
+    "to": "aws_s3_bucket.d"
+  }
+}
+`
+		if !strings.Contains(string(gen), `"d": {`) || !strings.HasSuffix(string(gen), want) {
+			t.Errorf("gen.tf.json =\n%s\nwant the d block and a moved block", gen)
+		}
+	})
+}
@@ -0,0 +1,528 @@
+package sort
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	gosort "sort"
+	"strings"
+
+	"github.com/dthagard/tforganize/internal/info"
+)
+
+// Report formats accepted by the report-format setting.
+const (
+	reportText              = "text"
+	reportJSON              = "json"
+	reportSARIF             = "sarif"
+	reportJUnit             = "junit"
+	reportCheckstyle        = "checkstyle"
+	reportGitHub            = "github"
+	reportGitLabCodeQuality = "gitlab-codequality"
+)
+
+// reportFormats lists the report formats in the order they are documented.
+var reportFormats = []string{reportText, reportJSON, reportSARIF, reportJUnit, reportCheckstyle, reportGitHub, reportGitLabCodeQuality}
+
+// File statuses used in reports.
+const (
+	fileUnchanged = "unchanged"
+	fileChanged   = "changed"
+	fileCreated   = "created"
+	fileRemoved   = "removed"
+)
+
+// reportRuleID identifies tforganize findings in SARIF, Checkstyle and
+// Code Quality reports.
+const reportRuleID = "unsorted"
+
+// lineRange is an inclusive, 1-based range of lines in the original file.
+type lineRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// fileResult is the outcome of sorting one output file.
+type fileResult struct {
+	Path   string      `json:"path"`
+	Status string      `json:"status"`
+	Ranges []lineRange `json:"ranges,omitempty"`
+}
+
+// runReport collects the file results of a run, including every directory
+// of a recursive run.
+type runReport struct {
+	files []fileResult
+}
+
+// validateReportFormat checks the report-format setting.
+func validateReportFormat(format string) error {
+	if format == "" || slices.Contains(reportFormats, format) {
+		return nil
+	}
+	return fmt.Errorf("unknown report format %q (expected one of %s)", format, strings.Join(reportFormats, ", "))
+}
+
+// reportEnabled reports whether a machine-readable report or a report file
+// was requested. The default text output of each mode is unchanged otherwise.
+func (s *Sorter) reportEnabled() bool {
+	return (s.params.ReportFormat != "" && s.params.ReportFormat != reportText) || s.params.ReportFile != ""
+}
+
+// recordResults compares each sorted file with the file it replaces and
+// adds the outcome to the run report. It must run before files are written.
+func (s *Sorter) recordResults(target string, inputFiles []string, sortedFiles map[string][]byte) error {
+	if s.report == nil {
+		return nil
+	}
+
+	for outputKey, sortedBytes := range sortedFiles {
+		originalPath, err := s.resolveOriginalPath(target, inputFiles, outputKey)
+		if err != nil {
+			// The created file lives in the target directory.
+			dir, dirErr := s.getDirectory(target)
+			if dirErr != nil {
+				dir = target
+			}
+			s.report.files = append(s.report.files, fileResult{Path: reportPath(filepath.Join(dir, outputKey)), Status: fileCreated})
+			continue
+		}
+
+		originalBytes, err := s.afs.ReadFile(originalPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				s.report.files = append(s.report.files, fileResult{Path: reportPath(originalPath), Status: fileCreated})
+				continue
+			}
+			return fmt.Errorf("report: could not read original file %s: %w", originalPath, err)
+		}
+
+		result := fileResult{Path: reportPath(originalPath), Status: fileUnchanged}
+		if !bytes.Equal(originalBytes, sortedBytes) {
+			result.Status = fileChanged
+			result.Ranges = changedRanges(string(originalBytes), string(sortedBytes))
+		}
+		s.report.files = append(s.report.files, result)
+	}
+
+	removed, err := s.removedFiles(target, inputFiles, sortedFiles)
+	if err != nil {
+		return fmt.Errorf("report: %w", err)
+	}
+	for _, f := range removed {
+		s.report.files = append(s.report.files, fileResult{Path: reportPath(f), Status: fileRemoved})
+	}
+	return nil
+}
+
+// changedRanges returns the line ranges of a that differ from b. Lines that
+// are only inserted are attributed to the line before them, or to line 1.
+// Ranges that share a line are merged.
+func changedRanges(a, b string) []lineRange {
+	aLines := splitLines(a)
+	edits := computeEdits(aLines, splitLines(b))
+
+	var ranges []lineRange
+	line := 0 // lines of a consumed so far
+	for i := 0; i < len(edits); {
+		if edits[i].kind == editEqual {
+			line++
+			i++
+			continue
+		}
+		start, end := line+1, line
+		for ; i < len(edits) && edits[i].kind != editEqual; i++ {
+			if edits[i].kind == editDelete {
+				line++
+				end = line
+			}
+		}
+		if end < start {
+			// Pure insertion: point at the preceding line.
+			start = max(line, 1)
+			end = start
+		}
+		// An insertion before line 1 and a change of line 1 share a line;
+		// report it once.
+		if n := len(ranges); n > 0 && start <= ranges[n-1].End {
+			ranges[n-1].End = max(ranges[n-1].End, end)
+			continue
+		}
+		ranges = append(ranges, lineRange{Start: start, End: end})
+	}
+	return ranges
+}
+
+// reportPath returns path relative to the working directory when it lies
+// below it, which is what CI annotations expect.
+func reportPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, abs); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return abs
+}
+
+// writeReport writes the run report in the configured format to the report
+// file, or to stdout when no report file is set.
+func (s *Sorter) writeReport() error {
+	if s.report == nil {
+		return nil
+	}
+	gosort.Slice(s.report.files, func(i, j int) bool { return s.report.files[i].Path < s.report.files[j].Path })
+
+	var buf bytes.Buffer
+	if err := s.formatReport(&buf); err != nil {
+		return fmt.Errorf("could not format report: %w", err)
+	}
+
+	if s.params.ReportFile == "" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := s.afs.WriteFile(s.params.ReportFile, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("could not write report file: %w", err)
+	}
+	return nil
+}
+
+// formatReport renders the run report.
+func (s *Sorter) formatReport(w io.Writer) error {
+	files := s.report.files
+	switch s.params.ReportFormat {
+	case reportJSON:
+		return writeJSONReport(w, files)
+	case reportSARIF:
+		return writeSARIFReport(w, files, s.findingLevel())
+	case reportJUnit:
+		return writeJUnitReport(w, files)
+	case reportCheckstyle:
+		return writeCheckstyleReport(w, files, s.findingLevel())
+	case reportGitHub:
+		return writeGitHubReport(w, files, s.findingLevel())
+	case reportGitLabCodeQuality:
+		return writeCodeQualityReport(w, files, s.params.Check)
+	}
+	return writeTextReport(w, files)
+}
+
+// findingLevel is the severity of an unsorted file: an error when the run
+// is a check, a warning otherwise.
+func (s *Sorter) findingLevel() string {
+	if s.params.Check {
+		return "error"
+	}
+	return "warning"
+}
+
+// findingMessage describes an unsorted file.
+func findingMessage(result fileResult) string {
+	switch result.Status {
+	case fileCreated:
+		return "file would be created by tforganize sort"
+	case fileRemoved:
+		return "file would be removed by tforganize sort"
+	}
+	return "file is not sorted; run tforganize sort"
+}
+
+// formatRanges renders ranges as "3-7, 12".
+func formatRanges(ranges []lineRange) string {
+	var parts []string
+	for _, r := range ranges {
+		if r.Start == r.End {
+			parts = append(parts, fmt.Sprintf("%d", r.Start))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", r.Start, r.End))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// findingRanges returns the ranges to annotate for a result, using the
+// first line when no range is known.
+func findingRanges(result fileResult) []lineRange {
+	if len(result.Ranges) > 0 {
+		return result.Ranges
+	}
+	return []lineRange{{Start: 1, End: 1}}
+}
+
+// writeTextReport writes one line per file with its status.
+func writeTextReport(w io.Writer, files []fileResult) error {
+	for _, f := range files {
+		line := fmt.Sprintf("%s: %s", f.Path, f.Status)
+		if len(f.Ranges) > 0 {
+			line += fmt.Sprintf(" (lines %s)", formatRanges(f.Ranges))
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeJSONReport writes the file results as a JSON document.
+func writeJSONReport(w io.Writer, files []fileResult) error {
+	changed := 0
+	for _, f := range files {
+		if f.Status != fileUnchanged {
+			changed++
+		}
+	}
+	if files == nil {
+		files = []fileResult{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Files   []fileResult `json:"files"`
+		Changed int          `json:"changed"`
+	}{files, changed})
+}
+
+// writeSARIFReport writes a SARIF log with one result per unsorted file.
+func writeSARIFReport(w io.Writer, files []fileResult, level string) error {
+	type region struct {
+		StartLine int `json:"startLine"`
+		EndLine   int `json:"endLine"`
+	}
+	type physicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region *region `json:"region,omitempty"`
+	}
+	type location struct {
+		PhysicalLocation physicalLocation `json:"physicalLocation"`
+	}
+	type message struct {
+		Text string `json:"text"`
+	}
+	type result struct {
+		RuleID    string     `json:"ruleId"`
+		Level     string     `json:"level"`
+		Message   message    `json:"message"`
+		Locations []location `json:"locations"`
+	}
+	type rule struct {
+		ID               string  `json:"id"`
+		ShortDescription message `json:"shortDescription"`
+	}
+	type driver struct {
+		Name           string `json:"name"`
+		Version        string `json:"version"`
+		InformationURI string `json:"informationUri"`
+		Rules          []rule `json:"rules"`
+	}
+	type run struct {
+		Tool struct {
+			Driver driver `json:"driver"`
+		} `json:"tool"`
+		Results []result `json:"results"`
+	}
+
+	var r run
+	r.Tool.Driver = driver{
+		Name:           info.AppName,
+		Version:        info.AppVersion,
+		InformationURI: fmt.Sprintf("https://github.com/%s/%s", info.AppRepoOwner, info.AppName),
+		Rules:          []rule{{ID: reportRuleID, ShortDescription: message{Text: "Terraform file is not sorted"}}},
+	}
+	r.Results = []result{}
+	for _, f := range files {
+		if f.Status == fileUnchanged {
+			continue
+		}
+		res := result{RuleID: reportRuleID, Level: level, Message: message{Text: findingMessage(f)}}
+		if len(f.Ranges) == 0 {
+			var loc location
+			loc.PhysicalLocation.ArtifactLocation.URI = f.Path
+			res.Locations = append(res.Locations, loc)
+		}
+		for _, lr := range f.Ranges {
+			var loc location
+			loc.PhysicalLocation.ArtifactLocation.URI = f.Path
+			loc.PhysicalLocation.Region = &region{StartLine: lr.Start, EndLine: lr.End}
+			res.Locations = append(res.Locations, loc)
+		}
+		r.Results = append(r.Results, res)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Schema  string `json:"$schema"`
+		Version string `json:"version"`
+		Runs    []run  `json:"runs"`
+	}{"https://json.schemastore.org/sarif-2.1.0.json", "2.1.0", []run{r}})
+}
+
+// writeJUnitReport writes a JUnit test suite with one test case per file.
+func writeJUnitReport(w io.Writer, files []fileResult) error {
+	type failure struct {
+		Message string `xml:"message,attr"`
+		Text    string `xml:",chardata"`
+	}
+	type testCase struct {
+		Name      string   `xml:"name,attr"`
+		ClassName string   `xml:"classname,attr"`
+		Failure   *failure `xml:"failure,omitempty"`
+	}
+	type testSuite struct {
+		XMLName   xml.Name   `xml:"testsuite"`
+		Name      string     `xml:"name,attr"`
+		Tests     int        `xml:"tests,attr"`
+		Failures  int        `xml:"failures,attr"`
+		TestCases []testCase `xml:"testcase"`
+	}
+
+	suite := testSuite{Name: info.AppName, Tests: len(files)}
+	for _, f := range files {
+		tc := testCase{Name: f.Path, ClassName: info.AppName}
+		if f.Status != fileUnchanged {
+			suite.Failures++
+			text := f.Status
+			if len(f.Ranges) > 0 {
+				text = fmt.Sprintf("lines %s", formatRanges(f.Ranges))
+			}
+			tc.Failure = &failure{Message: findingMessage(f), Text: text}
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+
+	return writeXML(w, struct {
+		XMLName xml.Name  `xml:"testsuites"`
+		Suite   testSuite `xml:"testsuite"`
+	}{Suite: suite})
+}
+
+// writeCheckstyleReport writes one Checkstyle error per changed range.
+func writeCheckstyleReport(w io.Writer, files []fileResult, level string) error {
+	type checkstyleError struct {
+		Line     int    `xml:"line,attr"`
+		Severity string `xml:"severity,attr"`
+		Message  string `xml:"message,attr"`
+		Source   string `xml:"source,attr"`
+	}
+	type checkstyleFile struct {
+		Name   string            `xml:"name,attr"`
+		Errors []checkstyleError `xml:"error"`
+	}
+
+	var out []checkstyleFile
+	for _, f := range files {
+		cf := checkstyleFile{Name: f.Path}
+		if f.Status != fileUnchanged {
+			for _, r := range findingRanges(f) {
+				cf.Errors = append(cf.Errors, checkstyleError{
+					Line:     r.Start,
+					Severity: level,
+					Message:  findingMessage(f),
+					Source:   info.AppName + "." + reportRuleID,
+				})
+			}
+		}
+		out = append(out, cf)
+	}
+
+	return writeXML(w, struct {
+		XMLName xml.Name         `xml:"checkstyle"`
+		Version string           `xml:"version,attr"`
+		Files   []checkstyleFile `xml:"file"`
+	}{Version: "4.3", Files: out})
+}
+
+// githubDataEscaper and githubPropertyEscaper escape the message and the
+// property values of GitHub Actions workflow commands.
+var (
+	githubDataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	githubPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+// writeGitHubReport writes one GitHub Actions annotation per changed range.
+func writeGitHubReport(w io.Writer, files []fileResult, level string) error {
+	for _, f := range files {
+		if f.Status == fileUnchanged {
+			continue
+		}
+		for _, r := range findingRanges(f) {
+			if _, err := fmt.Fprintf(w, "::%s file=%s,line=%d,endLine=%d,title=%s::%s\n",
+				level, githubPropertyEscaper.Replace(f.Path), r.Start, r.End,
+				githubPropertyEscaper.Replace(info.AppName), githubDataEscaper.Replace(findingMessage(f))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeCodeQualityReport writes one GitLab Code Quality issue per changed range.
+func writeCodeQualityReport(w io.Writer, files []fileResult, check bool) error {
+	type lines struct {
+		Begin int `json:"begin"`
+		End   int `json:"end"`
+	}
+	type location struct {
+		Path  string `json:"path"`
+		Lines lines  `json:"lines"`
+	}
+	type issue struct {
+		Description string   `json:"description"`
+		CheckName   string   `json:"check_name"`
+		Fingerprint string   `json:"fingerprint"`
+		Severity    string   `json:"severity"`
+		Location    location `json:"location"`
+	}
+
+	severity := "minor"
+	if check {
+		severity = "major"
+	}
+	issues := []issue{}
+	for _, f := range files {
+		if f.Status == fileUnchanged {
+			continue
+		}
+		for _, r := range findingRanges(f) {
+			sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%s:%d:%d", reportRuleID, f.Path, r.Start, r.End)))
+			issues = append(issues, issue{
+				Description: findingMessage(f),
+				CheckName:   info.AppName + "-" + reportRuleID,
+				Fingerprint: hex.EncodeToString(sum[:]),
+				Severity:    severity,
+				Location:    location{Path: f.Path, Lines: lines{Begin: r.Start, End: r.End}},
+			})
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(issues)
+}
+
+// writeXML writes v as an indented XML document.
+func writeXML(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
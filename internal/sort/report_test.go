@@ -0,0 +1,236 @@
+package sort
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"reflect"
+	gosort "sort"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestChangedRanges(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []lineRange
+	}{
+		{name: "identical", a: "a\nb\n", b: "a\nb\n", want: nil},
+		{name: "changed line", a: "a\nb\nc\n", b: "a\nx\nc\n", want: []lineRange{{2, 2}}},
+		{name: "swapped lines", a: "a\nc\nb\n", b: "a\nb\nc\n", want: []lineRange{{2, 2}, {3, 3}}},
+		{name: "deleted lines", a: "a\nb\nc\nd\n", b: "a\nd\n", want: []lineRange{{2, 3}}},
+		{name: "inserted line", a: "a\nb\n", b: "a\nx\nb\n", want: []lineRange{{1, 1}}},
+		{name: "inserted at top", a: "a\n", b: "x\na\n", want: []lineRange{{1, 1}}},
+		{name: "inserted before and after line 1", a: "a\nb\n", b: "x\na\ny\nb\n", want: []lineRange{{1, 1}}},
+		{name: "inserted at top and line 1 changed", a: "a\nb\nc\n", b: "x\ny\nb\nc\n", want: []lineRange{{1, 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := changedRanges(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changedRanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateReportFormat(t *testing.T) {
+	for _, format := range append([]string{""}, reportFormats...) {
+		if err := validateReportFormat(format); err != nil {
+			t.Errorf("validateReportFormat(%q) error: %v", format, err)
+		}
+	}
+	if err := validateReportFormat("xml"); err == nil || !strings.Contains(err.Error(), "unknown report format") {
+		t.Errorf("expected unknown report format error, got: %v", err)
+	}
+}
+
+func TestReportFlagConflicts(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/test", 0755)
+	_ = afero.WriteFile(memFS, "/test/main.tf", sortedSingleBlock, 0644)
+
+	tests := []struct {
+		name    string
+		params  Params
+		wantErr bool
+	}{
+		{name: "json to stdout with sorted output", params: Params{ReportFormat: reportJSON}, wantErr: true},
+		{name: "json to stdout with diff", params: Params{ReportFormat: reportJSON, Diff: true}, wantErr: true},
+		{name: "json to stdout with check", params: Params{ReportFormat: reportJSON, Check: true}},
+		{name: "json to file with diff", params: Params{ReportFormat: reportJSON, Diff: true, ReportFile: "/report.json"}},
+		{name: "text to stdout", params: Params{ReportFormat: reportText}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			err := NewSorter(&params, memFS).run("/test")
+			if tt.wantErr != (err != nil) {
+				t.Errorf("run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckReportJSON(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/report/nested", 0755)
+	_ = afero.WriteFile(memFS, "/report/a.tf", []byte("variable \"region\" {\n  type = string\n}\n"), 0644)
+	_ = afero.WriteFile(memFS, "/report/b.tf", unsortedTwoBlocks, 0644)
+	_ = afero.WriteFile(memFS, "/report/nested/c.tf", unsortedTwoBlocks, 0644)
+	s := NewSorter(&Params{Check: true, ReportFormat: reportJSON, ReportFile: "/out/report.json"}, memFS)
+	if err := s.run("/report"); !errors.Is(err, ErrCheckFailed) {
+		t.Fatalf("expected ErrCheckFailed, got: %v", err)
+	}
+
+	content, err := afero.ReadFile(memFS, "/out/report.json")
+	if err != nil {
+		t.Fatalf("report not written: %v", err)
+	}
+	var report struct {
+		Files   []fileResult `json:"files"`
+		Changed int          `json:"changed"`
+	}
+	if err := json.Unmarshal(content, &report); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, content)
+	}
+
+	want := []fileResult{
+		{Path: "/report/a.tf", Status: fileUnchanged},
+		{Path: "/report/b.tf", Status: fileChanged, Ranges: []lineRange{{1, 4}, {7, 7}}},
+	}
+	if !reflect.DeepEqual(report.Files, want) || report.Changed != 1 {
+		t.Errorf("report = %+v, want files %+v and 1 changed", report, want)
+	}
+}
+
+func TestRecordResultsCreatedFileInTarget(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/report", 0755)
+	_ = afero.WriteFile(memFS, "/report/a.tf", sortedSingleBlock, 0644)
+	s := NewSorter(&Params{Check: true, ReportFormat: reportJSON}, memFS)
+	s.report = &runReport{}
+
+	sortedFiles := map[string][]byte{"a.tf": sortedSingleBlock, "versions.tf": []byte("terraform {}\n")}
+	if err := s.recordResults("/report", []string{"/report/a.tf"}, sortedFiles); err != nil {
+		t.Fatalf("recordResults() error: %v", err)
+	}
+	gosort.Slice(s.report.files, func(i, j int) bool { return s.report.files[i].Path < s.report.files[j].Path })
+
+	want := []fileResult{
+		{Path: "/report/a.tf", Status: fileUnchanged},
+		{Path: "/report/versions.tf", Status: fileCreated},
+	}
+	if !reflect.DeepEqual(s.report.files, want) {
+		t.Errorf("report files = %+v, want %+v", s.report.files, want)
+	}
+}
+
+func TestRecursiveReport(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/report/nested", 0755)
+	_ = afero.WriteFile(memFS, "/report/a.tf", []byte("variable \"region\" {\n  type = string\n}\n"), 0644)
+	_ = afero.WriteFile(memFS, "/report/b.tf", unsortedTwoBlocks, 0644)
+	_ = afero.WriteFile(memFS, "/report/nested/c.tf", unsortedTwoBlocks, 0644)
+	s := NewSorter(&Params{Recursive: true, Inline: true, ReportFormat: reportCheckstyle, ReportFile: "/report.xml"}, memFS)
+	if err := s.run("/report"); err != nil {
+		t.Fatalf("run() error: %v", err)
+	}
+
+	content, err := afero.ReadFile(memFS, "/report.xml")
+	if err != nil {
+		t.Fatalf("report not written: %v", err)
+	}
+	var report struct {
+		Files []struct {
+			Name   string `xml:"name,attr"`
+			Errors []struct {
+				Line     int    `xml:"line,attr"`
+				Severity string `xml:"severity,attr"`
+			} `xml:"error"`
+		} `xml:"file"`
+	}
+	if err := xml.Unmarshal(content, &report); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, content)
+	}
+	if len(report.Files) != 3 {
+		t.Fatalf("expected 3 files, got %d:\n%s", len(report.Files), content)
+	}
+	for _, f := range report.Files {
+		wantErrors := 2
+		if f.Name == "/report/a.tf" {
+			wantErrors = 0
+		}
+		if len(f.Errors) != wantErrors {
+			t.Errorf("%s: expected %d errors, got %d", f.Name, wantErrors, len(f.Errors))
+		}
+		for _, e := range f.Errors {
+			if e.Severity != "warning" {
+				t.Errorf("%s: unexpected error %+v", f.Name, e)
+			}
+		}
+	}
+
+	// The report describes the files before they were sorted in place.
+	sorted, _ := afero.ReadFile(memFS, "/report/nested/c.tf")
+	if string(sorted) == string(unsortedTwoBlocks) {
+		t.Error("expected nested/c.tf to be sorted in place")
+	}
+}
+
+func TestReportFormats(t *testing.T) {
+	files := []fileResult{
+		{Path: "a.tf", Status: fileUnchanged},
+		{Path: "b.tf", Status: fileChanged, Ranges: []lineRange{{2, 4}, {9, 9}}},
+		{Path: "versions.tf", Status: fileCreated},
+	}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{format: reportText, want: []string{"a.tf: unchanged\n", "b.tf: changed (lines 2-4, 9)\n", "versions.tf: created\n"}},
+		{format: reportJSON, want: []string{`"changed": 2`, `"status": "created"`, `"start": 9`}},
+		{format: reportSARIF, want: []string{`"version": "2.1.0"`, `"name": "tforganize"`, `"ruleId": "unsorted"`, `"level": "error"`, `"startLine": 2`, `"endLine": 4`}},
+		{format: reportJUnit, want: []string{`<testsuite name="tforganize" tests="3" failures="2">`, `<testcase name="a.tf" classname="tforganize"></testcase>`, `lines 2-4, 9</failure>`}},
+		{format: reportCheckstyle, want: []string{`<file name="a.tf"></file>`, `<error line="9" severity="error"`, `source="tforganize.unsorted"`}},
+		{format: reportGitHub, want: []string{"::error file=b.tf,line=2,endLine=4,title=tforganize::file is not sorted", "::error file=versions.tf,line=1,endLine=1,"}},
+		{format: reportGitLabCodeQuality, want: []string{`"check_name": "tforganize-unsorted"`, `"severity": "major"`, `"path": "b.tf"`, `"begin": 9`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			s := NewSorter(&Params{Check: true, ReportFormat: tt.format}, afero.NewMemMapFs())
+			s.report = &runReport{files: files}
+			var buf strings.Builder
+			if err := s.formatReport(&buf); err != nil {
+				t.Fatalf("formatReport() error: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("expected report to contain %q, got:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestGitHubReportEscaping(t *testing.T) {
+	var buf strings.Builder
+	files := []fileResult{{Path: "env:prod/a,b%.tf", Status: fileChanged, Ranges: []lineRange{{1, 2}}}}
+	if err := writeGitHubReport(&buf, files, "warning"); err != nil {
+		t.Fatalf("writeGitHubReport() error: %v", err)
+	}
+	want := "::warning file=env%3Aprod/a%2Cb%25.tf,line=1,endLine=2,title=tforganize::file is not sorted; run tforganize sort\n"
+	if buf.String() != want {
+		t.Errorf("writeGitHubReport() = %q, want %q", buf.String(), want)
+	}
+
+	if got := githubDataEscaper.Replace("50% done\r\nnext: a,b"); got != "50%25 done%0D%0Anext: a,b" {
+		t.Errorf("githubDataEscaper.Replace() = %q", got)
+	}
+}
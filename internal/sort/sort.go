@@ -16,19 +16,63 @@ import (
 const (
 	blockTypeLabel   = "block.Type"
 	blockLabelsLabel = "block.Labels"
+	// combinedFileName is the name given to the files concatenated by
+	// group-by-type.
+	combinedFileName = "combined.tf"
 )
 
 // sortFiles sorts a list of files.
 func (s *Sorter) sortFiles(files []string) (map[string][]byte, error) {
 	log.WithField("files", files).Traceln("Starting sortFiles")
+	s.inputFiles = files
+
+	if err := s.checkDuplicateBlocksInFiles(files); err != nil {
+		return nil, err
+	}
 
 	if s.params.GroupByType {
+		// JSON, variable definitions, test and mock data files cannot be
+		// combined with module files; they are sorted on their own and keep
+		// their names.
+		output := map[string][]byte{}
+		var native []string
+		for _, f := range files {
+			if !sortsAlone(f) {
+				native = append(native, f)
+				continue
+			}
+			sorted, err := s.sortFile(f)
+			if err != nil {
+				return nil, fmt.Errorf("could not sort file %s: %w", f, err)
+			}
+			for k, v := range sorted {
+				output[k] = v
+			}
+		}
+
+		files, err := s.filesWithBlocks(native)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return output, nil
+		}
 		log.Debugln("Creating combined file...")
 		combinedBytes, err := s.combineFiles(files)
 		if err != nil {
 			return nil, fmt.Errorf("could not combine files: %w", err)
 		}
-		return s.sortFileBytes(combinedBytes, "combined.tf")
+		sorted, err := s.sortFileBytes(combinedBytes, combinedFileName)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range sorted {
+			if _, ok := output[k]; ok {
+				return nil, fmt.Errorf("could not write %s: the name is used by both a grouped file and an input file", k)
+			}
+			output[k] = v
+		}
+		return output, nil
 	}
 
 	// Process files in parallel when there are multiple files.
@@ -68,6 +112,22 @@ func (s *Sorter) sortFiles(files []string) (map[string][]byte, error) {
 func (s *Sorter) sortFile(path string) (map[string][]byte, error) {
 	log.WithField("path", path).Traceln("Starting sortFile")
 
+	if isTestFile(path) && !s.testProfile {
+		return s.testFileSorter().sortFile(path)
+	}
+
+	if isJSONSyntax(path) {
+		content, err := s.afs.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read file: %w", err)
+		}
+		sorted, err := s.sortJSONContent(content, path)
+		if err != nil {
+			return nil, err
+		}
+		return map[string][]byte{getFileNameFromPath(path): sorted}, nil
+	}
+
 	body, err := s.parseHclFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("could not get body from file: %w", err)
@@ -88,6 +148,18 @@ func (s *Sorter) sortFile(path string) (map[string][]byte, error) {
 func (s *Sorter) sortFileBytes(content []byte, filename string) (map[string][]byte, error) {
 	log.WithField("filename", filename).Traceln("Starting sortFileBytes")
 
+	if isTestFile(filename) && !s.testProfile {
+		return s.testFileSorter().sortFileBytes(content, filename)
+	}
+
+	if isJSONSyntax(filename) {
+		sorted, err := s.sortJSONContent(content, filename)
+		if err != nil {
+			return nil, err
+		}
+		return map[string][]byte{getFileNameFromPath(filename): sorted}, nil
+	}
+
 	s.cacheLinesFromBytes(content, filename)
 
 	// Detect the file header before sorting.
@@ -109,8 +181,21 @@ func (s *Sorter) sortFileBytes(content []byte, filename string) (map[string][]by
 // inputFilename is the original file path, used to look up the pre-detected
 // header for correct re-addition when --keep-header is set.
 func (s *Sorter) sortBody(body *hclsyntax.Body, inputFilename string) (map[string][]byte, error) {
-	log.Debugln("Sorting blocks...")
-	sortedFileBytes, err := s.sortBlocks(body.Blocks)
+	// Variable definitions files without assignments and test or mock data
+	// files without blocks are kept as written.
+	if len(body.Blocks) == 0 && (isTestFile(inputFilename) || isVarsFile(inputFilename) && len(body.Attributes) == 0) {
+		return s.unchangedFile(inputFilename)
+	}
+
+	var sortedFileBytes map[string][]byte
+	var err error
+	if isVarsFile(inputFilename) {
+		log.Debugln("Sorting variable assignments...")
+		sortedFileBytes, err = s.sortVariableAssignments(body, inputFilename)
+	} else {
+		log.Debugln("Sorting blocks...")
+		sortedFileBytes, err = s.sortBlocks(body.Blocks)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("could not sort blocks: %w", err)
 	}
@@ -122,9 +207,14 @@ func (s *Sorter) sortBody(body *hclsyntax.Body, inputFilename string) (map[strin
 			log.Debugln("Adding header...")
 			buffer = s.addHeader(buffer, inputFilename)
 		}
-		formatted := hclwrite.Format(buffer)
+		// The blocks scope leaves block bodies byte-for-byte untouched, so
+		// the output is not reformatted.
+		formatted := buffer
+		if s.params.Scope != scopeBlocks {
+			formatted = hclwrite.Format(buffer)
+		}
 
-		if s.params.CompactEmptyBlocks {
+		if s.params.CompactEmptyBlocks && s.params.Scope != scopeBlocks {
 			re := regexp.MustCompile(`(?m)(\S) \{\s*\n\}\n`)
 			formatted = re.ReplaceAll(formatted, []byte("$1 {}\n"))
 		}
@@ -147,30 +237,44 @@ func (s *Sorter) sortBlocks(blocks hclsyntax.Blocks) (map[string][]byte, error)
 	// Initialize the output
 	output := map[string][]byte{}
 
-	sort.Stable(BlockListSorter{
-		blocks:     blocks,
-		sortByType: !s.params.NoSortByType,
-	})
-	log.WithField("blocks", blocks).Debugln("Got back sorted blocks from BlockListSorter")
+	// The bodies scope keeps the top-level block order untouched.
+	if s.params.Scope != scopeBodies {
+		s.orderBlocks(blocks)
+	}
+
+	// Resolve the output file of each block
+	outputKeys := make([]string, len(blocks))
+	for i, block := range blocks {
+		outputKey := getFileNameFromPath(block.TypeRange.Filename)
+		if s.params.GroupByType {
+			var err error
+			outputKey, err = s.getOutputFileForBlock(block)
+			if err != nil {
+				return nil, fmt.Errorf("could not route block: %w", err)
+			}
+		}
+		outputKeys[i] = outputKey
+	}
+
+	if s.params.MergeBlocks {
+		var err error
+		blocks, outputKeys, err = s.mergeBlocks(blocks, outputKeys)
+		if err != nil {
+			return nil, fmt.Errorf("could not merge blocks: %w", err)
+		}
+	}
 
 	// Iterate through each block and order its attributes and child blocks
-	for _, block := range blocks {
+	for i, block := range blocks {
 		log.WithFields(log.Fields{blockTypeLabel: block.Type, blockLabelsLabel: block.Labels, "block.Body": block.Body}).Debugln("Starting block iteration")
 
 		// Sort the block
-		blockBytes, err := s.getSortedBlockBytes(block)
+		blockBytes, err := s.getSortedBlockBytes(block, 1, blockPathSegment(block))
 		if err != nil {
 			return nil, fmt.Errorf("could not sort block: %w", err)
 		}
 
-		outputKey := getFileNameFromPath(block.TypeRange.Filename)
-		if s.params.GroupByType {
-			outputKey = defaultFileGroup
-			if v, ok := fileGroups[block.Type]; ok {
-				outputKey = v
-			}
-		}
-
+		outputKey := outputKeys[i]
 		output[outputKey] = addNewLineIfBufferExists(output[outputKey])
 		output[outputKey] = append(output[outputKey], blockBytes...)
 	}
@@ -178,12 +282,43 @@ func (s *Sorter) sortBlocks(blocks hclsyntax.Blocks) (map[string][]byte, error)
 	return output, nil
 }
 
+// orderBlocks sorts top-level blocks in place using the block type order,
+// the per-type comparators and, when enabled, their dependencies.
+func (s *Sorter) orderBlocks(blocks hclsyntax.Blocks) {
+	sort.Stable(s.blockListSorter(blocks))
+	log.WithField("blocks", blocks).Debugln("Got back sorted blocks from BlockListSorter")
+
+	if s.params.OrderByDependencies {
+		orderBlocksByDependencies(blocks)
+	}
+}
+
+// blockListSorter returns the sorter that orders top-level blocks by the
+// configured block type order, comparators and collation.
+func (s *Sorter) blockListSorter(blocks hclsyntax.Blocks) BlockListSorter {
+	return BlockListSorter{
+		blocks:      blocks,
+		sortByType:  !s.params.NoSortByType,
+		typeOrder:   s.typeOrder,
+		comparators: s.comparators,
+		collation:   s.collation,
+	}
+}
+
 // getSortedBlockBytes recursively sorts a block based on its attributes and child blocks.
-func (s *Sorter) getSortedBlockBytes(block *hclsyntax.Block) ([]byte, error) {
-	log.WithFields(log.Fields{blockTypeLabel: block.Type, blockLabelsLabel: block.Labels}).Traceln("Starting getSortedBlockBytes")
+// depth is the nesting depth of the block, starting at 1 for top-level blocks,
+// and blockPath is its dotted path used to match list-paths.
+// Blocks outside the configured scope or deeper than body-depth are copied
+// verbatim.
+func (s *Sorter) getSortedBlockBytes(block *hclsyntax.Block, depth int, blockPath string) ([]byte, error) {
+	log.WithFields(log.Fields{blockTypeLabel: block.Type, blockLabelsLabel: block.Labels, "depth": depth}).Traceln("Starting getSortedBlockBytes")
+
+	if !s.sortsBodyAt(depth) {
+		return s.getRawBlockBytes(block)
+	}
 
 	// Sort the block keys
-	keys := getSortedBlockKeys(block)
+	keys := s.getSortedBlockKeys(block)
 
 	// Write the block opening
 	results, err := s.getBlockOpeningBytes(block)
@@ -201,7 +336,7 @@ func (s *Sorter) getSortedBlockBytes(block *hclsyntax.Block) ([]byte, error) {
 		if len(keys[i]) > 0 {
 			log.WithFields(log.Fields{"i": i, "keys[i]": keys[i]}).Debugln("Using keys")
 			buffer = addNewLineIfBufferExists(buffer)
-			blockBytes, err := s.getBlockBodyBytes(block, keys[i])
+			blockBytes, err := s.getBlockBodyBytes(block, keys[i], depth, blockPath)
 			if err != nil {
 				return nil, fmt.Errorf("could not append label to output: %w", err)
 			}
@@ -220,11 +355,11 @@ func (s *Sorter) getSortedBlockBytes(block *hclsyntax.Block) ([]byte, error) {
 // getSortedBlockKeys returns a sorted map of the block attributes and child blocks
 // grouped into three separate categories:
 // 0. Pre-Meta Arguments
-// 1. Arguments
+// 1. Arguments (alphabetical, or ordered by an argument profile)
 // 2. Post-Meta Arguments
 // This is done to ensure that the arguments are sorted in the correct order.
 // See https://www.terraform.io/docs/configuration/syntax.html
-func getSortedBlockKeys(block *hclsyntax.Block) map[int][]string {
+func (s *Sorter) getSortedBlockKeys(block *hclsyntax.Block) map[int][]string {
 	log.WithFields(log.Fields{blockTypeLabel: block.Type, blockLabelsLabel: block.Labels}).Traceln("Starting getSortedBlockKeys")
 
 	// Initialize the return map
@@ -234,7 +369,7 @@ func getSortedBlockKeys(block *hclsyntax.Block) map[int][]string {
 	}
 
 	// Get the meta arguments for the block type
-	metaArgs := getMetaArguments(block)
+	metaArgs := lookupMetaArguments(s.metaArguments, block)
 
 	// Categorize the body attributes
 	for k := range block.Body.Attributes {
@@ -265,31 +400,91 @@ func getSortedBlockKeys(block *hclsyntax.Block) map[int][]string {
 	}
 
 	// Sort the pre-meta blocks and attributes by the order of the meta arguments
-	if len(keys[0]) > 0 {
-		sort.SliceStable(keys[0], func(i, j int) bool {
-			key1 := keys[0][i]
-			key2 := keys[0][j]
-
-			for _, arg := range metaArgs[0] {
-				if arg == key1 {
-					return true
-				} else if arg == key2 {
-					return false
-				}
-			}
-			return false
-		})
+	sortKeysByMetaArgs(keys[0], metaArgs[0])
+
+	// Sort the non-meta blocks and attributes alphabetically, then apply the
+	// argument profile of the resource or data source type, if any.
+	s.sortKeys(keys[1])
+	if profile, ok := s.getArgumentProfile(block); ok {
+		applyArgumentProfile(keys[1], profile, s.keyName)
+	}
+	if s.params.OrderByDependencies && block.Type == "locals" {
+		keys[1] = orderLocalsByDependencies(block, keys[1])
 	}
 
-	// Sort the post and non-meta blocks and attributes alphabetically
-	for i := 1; i < 3; i++ {
-		sort.Strings(keys[i])
+	// Sort the post-meta blocks and attributes alphabetically, unless the
+	// post list was configured by the user, in which case its order is kept
+	// (e.g. so that tags can always come last).
+	if override, ok := s.params.MetaArguments[block.Type]; ok && override.Post != nil {
+		sortKeysByMetaArgs(keys[2], metaArgs[1])
+	} else {
+		s.sortKeys(keys[2])
 	}
 
 	log.WithField("keys", keys).Debugln("Returning sorted keys")
 	return keys
 }
 
+// sortKeys sorts block keys using the configured collation.
+func (s *Sorter) sortKeys(keys []string) {
+	sort.SliceStable(keys, func(i, j int) bool {
+		return s.keyLess(keys[i], keys[j])
+	})
+}
+
+// keyLess compares two block keys. Unless dynamic-blocks is "separate",
+// a dynamic block is compared by its label so that it sorts next to static
+// blocks of the same name, before or after them as configured.
+func (s *Sorter) keyLess(key1, key2 string) bool {
+	if s.params.DynamicBlocks == "" || s.params.DynamicBlocks == dynamicSeparate {
+		return s.collation.less(key1, key2)
+	}
+
+	name1, dynamic1 := s.keyName(key1)
+	name2, dynamic2 := s.keyName(key2)
+	if name1 != name2 {
+		return s.collation.less(name1, name2)
+	}
+	if dynamic1 != dynamic2 {
+		return dynamic1 == (s.params.DynamicBlocks == dynamicDynamicFirst)
+	}
+	return s.collation.less(key1, key2)
+}
+
+// keyName returns the name a block key is sorted and matched by. For dynamic
+// blocks this is the generated block type when dynamic blocks are sorted with
+// their static siblings; otherwise it is the key itself.
+func (s *Sorter) keyName(key string) (string, bool) {
+	if s.params.DynamicBlocks == "" || s.params.DynamicBlocks == dynamicSeparate {
+		return key, false
+	}
+	if label, ok := strings.CutPrefix(key, "dynamic "); ok {
+		return label, true
+	}
+	return key, false
+}
+
+// sortKeysByMetaArgs sorts keys by the position of their argument or block
+// type in metaArgs. Keys sharing a position keep their relative order.
+func sortKeysByMetaArgs(keys []string, metaArgs []string) {
+	if len(keys) == 0 {
+		return
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		key1 := strings.SplitN(keys[i], " ", 2)[0]
+		key2 := strings.SplitN(keys[j], " ", 2)[0]
+
+		for _, arg := range metaArgs {
+			if arg == key1 {
+				return arg != key2
+			} else if arg == key2 {
+				return false
+			}
+		}
+		return false
+	})
+}
+
 // formatBlockKey returns a formatted string of a block's type and labels.
 func formatBlockKey(block *hclsyntax.Block) string {
 	log.WithFields(log.Fields{blockTypeLabel: block.Type, blockLabelsLabel: block.Labels}).Traceln("Starting formatBlockKey")
@@ -317,6 +512,9 @@ func (s *Sorter) getBlockOpeningBytes(block *hclsyntax.Block) ([]byte, error) {
 		startLine := block.TypeRange.Start.Line - 1 // Subtract 1 to account for 0-indexing of string arrays vs HCL line numbers
 		nodeComment := s.getNodeComment(lines, startLine, block.TypeRange.Filename)
 
+		// Comments of blocks folded in by merge-blocks follow the block's own.
+		nodeComment = append(nodeComment, s.getFoldedComment(block)...)
+
 		if len(nodeComment) > 0 {
 			output = append(output, []byte(fmt.Sprintf("%s\n", strings.Join(nodeComment, "\n")))...)
 		}
@@ -337,7 +535,8 @@ func (s *Sorter) getBlockOpeningBytes(block *hclsyntax.Block) ([]byte, error) {
 }
 
 // getBlockBodyBytes returns the byte array of all the attributes and child blocks of a block.
-func (s *Sorter) getBlockBodyBytes(block *hclsyntax.Block, keys []string) ([]byte, error) {
+// depth and blockPath are the nesting depth and dotted path of block.
+func (s *Sorter) getBlockBodyBytes(block *hclsyntax.Block, keys []string, depth int, blockPath string) ([]byte, error) {
 	log.WithFields(log.Fields{blockTypeLabel: block.Type, blockLabelsLabel: block.Labels}).Traceln("Starting getBlockBodyBytes")
 
 	var output []byte
@@ -368,6 +567,8 @@ func (s *Sorter) getBlockBodyBytes(block *hclsyntax.Block, keys []string) ([]byt
 			if err != nil {
 				return nil, fmt.Errorf("could not write attribute: %w", err)
 			}
+			b = s.sortObjectKeys(block, attribute.Name, b)
+			b = s.sortListLiterals(blockPath+"."+attribute.Name, attribute.Name, b)
 			buffer = append(buffer, b...)
 			continue
 		}
@@ -382,7 +583,7 @@ func (s *Sorter) getBlockBodyBytes(block *hclsyntax.Block, keys []string) ([]byt
 
 				log.WithField("childBlock", childBlock).Debugln("Found child block in blocks")
 				buffer = addNewLineIfBufferExists(buffer)
-				b, err := s.getSortedBlockBytes(childBlock)
+				b, err := s.getSortedBlockBytes(childBlock, depth+1, blockPath+"."+childBlock.Type)
 				if err != nil {
 					return nil, fmt.Errorf("could not sort block: %w", err)
 				}
@@ -439,6 +640,31 @@ func (s *Sorter) getAttributeBytes(attribute *hclsyntax.Attribute, path string)
 	return b, nil
 }
 
+// getRawBlockBytes returns the source text of a block, including its leading
+// comment, without reordering anything inside it.
+func (s *Sorter) getRawBlockBytes(block *hclsyntax.Block) ([]byte, error) {
+	log.WithFields(log.Fields{blockTypeLabel: block.Type, blockLabelsLabel: block.Labels}).Traceln("Starting getRawBlockBytes")
+
+	path, err := getPathFromBlock(block)
+	if err != nil {
+		return nil, fmt.Errorf("could not get path from block: %w", err)
+	}
+
+	blockRange := block.Range()
+	content, err := s.readNodeFromFile(
+		path,
+		blockRange.Start.Line,
+		blockRange.Start.Column,
+		blockRange.End.Line,
+		blockRange.End.Column,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not read file contents: %w", err)
+	}
+
+	return []byte(strings.Join(content, "\n") + "\n"), nil
+}
+
 // getBlockClosingBytes returns the closing byte array of a block.
 func getBlockClosingBytes(block *hclsyntax.Block) []byte {
 	log.WithFields(log.Fields{blockTypeLabel: block.Type, blockLabelsLabel: block.Labels}).Traceln("Starting getBlockClosingBytes")
@@ -1,8 +1,10 @@
 package sort
 
 import (
+	"fmt"
 	"path/filepath"
 	"sort"
+	"strings"
 	"testing"
 
 	hclsyntax "github.com/hashicorp/hcl/v2/hclsyntax"
@@ -49,3 +51,77 @@ func BenchmarkBlockListSorter(b *testing.B) {
 		sort.Stable(BlockListSorter{blocks: cp, sortByType: true})
 	}
 }
+
+// generatedResources returns a Terraform file of n resource blocks, each
+// five lines long, in the given label order.
+func generatedResources(labels []int) string {
+	var b strings.Builder
+	for i, label := range labels {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "resource \"aws_s3_bucket\" \"bucket_%05d\" {\n  bucket = \"bucket-%05d\"\n  acl    = \"private\"\n}\n", label, label)
+	}
+	return b.String()
+}
+
+func BenchmarkUnifiedDiffLarge(b *testing.B) {
+	const blocks = 2000 // about 10k lines
+
+	sorted := make([]int, blocks)
+	for i := range sorted {
+		sorted[i] = i
+	}
+	// A handful of blocks out of place, as in a typical unsorted file.
+	fewMoves := append([]int(nil), sorted...)
+	for i := 0; i < 10; i++ {
+		j := i * blocks / 10
+		fewMoves[j], fewMoves[j+1] = fewMoves[j+1], fewMoves[j]
+	}
+	reversed := make([]int, blocks)
+	for i := range reversed {
+		reversed[i] = blocks - 1 - i
+	}
+
+	cases := []struct {
+		name   string
+		before []int
+	}{
+		{name: "identical", before: sorted},
+		{name: "few moves", before: fewMoves},
+		{name: "reversed", before: reversed},
+	}
+
+	after := generatedResources(sorted)
+	for _, c := range cases {
+		before := generatedResources(c.before)
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(before) + len(after)))
+			for i := 0; i < b.N; i++ {
+				unifiedDiff("main.tf", "main.tf", before, after)
+			}
+		})
+	}
+}
+
+func BenchmarkComputeEditsLarge(b *testing.B) {
+	after := splitLines(generatedResources(func() []int {
+		labels := make([]int, 2000)
+		for i := range labels {
+			labels[i] = i
+		}
+		return labels
+	}()))
+	// Change one line in every hundred.
+	before := append([]string(nil), after...)
+	for i := 0; i < len(before); i += 100 {
+		before[i] = "# changed\n"
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		computeEdits(before, after)
+	}
+}
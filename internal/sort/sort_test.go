@@ -58,10 +58,14 @@ func TestSortErrorPaths(t *testing.T) {
 		}
 	})
 
-	t.Run("inline conflicts with group-by-type", func(t *testing.T) {
-		err := Sort(".", &Params{Inline: true, GroupByType: true})
-		if err == nil {
-			t.Fatal("expected error when inline conflicts with group-by-type, got nil")
+	t.Run("inline group-by-type requires a directory", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "main.tf")
+		if err := os.WriteFile(path, []byte("variable \"a\" {}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		err := Sort(path, &Params{Inline: true, GroupByType: true})
+		if err == nil || !strings.Contains(err.Error(), "requires a directory target") {
+			t.Fatalf("expected directory target error, got: %v", err)
 		}
 	})
 
@@ -187,6 +191,16 @@ func TestSortFile(t *testing.T) {
 		testSortFile(path, t)
 	})
 
+	/*********************************************************************/
+	// Test and mock data files use the test-file profile: run blocks keep
+	// their order and their arguments follow the canonical run order.
+	/*********************************************************************/
+
+	t.Run("test files", func(t *testing.T) {
+		path := filepath.Join(testDataDir, "test_files")
+		testSortFile(path, t)
+	})
+
 	t.Run("terraform block", func(t *testing.T) {
 		path := filepath.Join(testDataDir, "terraform_block")
 		testSortFile(path, t)
@@ -527,13 +541,18 @@ func TestGetLineSlice(t *testing.T) {
 func TestSort(t *testing.T) {
 
 	/*********************************************************************/
-	// Conflicting flags (Inline + GroupByType) should return early
-	// without panicking.
+	// Inline group-by-type on an empty directory writes nothing.
 	/*********************************************************************/
 
-	t.Run("conflicting flags returns early", func(t *testing.T) {
+	t.Run("inline group-by-type on empty directory", func(t *testing.T) {
 		dir := t.TempDir()
-		_ = Sort(dir, &Params{Inline: true, GroupByType: true})
+		if err := Sort(dir, &Params{Inline: true, GroupByType: true}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 0 {
+			t.Errorf("expected no files to be written, got %d", len(entries))
+		}
 	})
 
 	/*********************************************************************/
@@ -1424,3 +1443,304 @@ func TestUnifiedDiff(t *testing.T) {
 		}
 	})
 }
+
+// TestSortBytesBlockOrderConfig verifies that block-order and
+// block-comparators drive the top-level ordering end to end.
+func TestSortBytesBlockOrderConfig(t *testing.T) {
+	input := []byte(`output "zeta" {
+  value = 1
+}
+
+resource "aws_s3_bucket" "b" {
+  bucket = "b"
+}
+
+module "network" {
+  source = "./network"
+}
+
+output "alpha" {
+  value = 2
+}
+`)
+	result, err := SortBytes(input, "main.tf", &Params{
+		BlockOrder:       []string{"module", "resource"},
+		BlockComparators: map[string]string{"output": "preserve-original"},
+	})
+	if err != nil {
+		t.Fatalf("SortBytes returned unexpected error: %v", err)
+	}
+
+	out := string(result)
+	order := []string{`module "network"`, `resource "aws_s3_bucket"`, `output "zeta"`, `output "alpha"`}
+	last := -1
+	for _, want := range order {
+		idx := strings.Index(out, want)
+		if idx == -1 {
+			t.Fatalf("expected %q in output, got:\n%s", want, out)
+		}
+		if idx < last {
+			t.Errorf("%q is out of order in output:\n%s", want, out)
+		}
+		last = idx
+	}
+
+	t.Run("invalid comparator is rejected", func(t *testing.T) {
+		_, err := SortBytes(input, "main.tf", &Params{BlockComparators: map[string]string{"output": "nope"}})
+		if err == nil || !strings.Contains(err.Error(), "block-comparators") {
+			t.Fatalf("expected block-comparators error, got: %v", err)
+		}
+	})
+}
+
+// TestSortBytesMetaArgumentsConfig verifies that configured meta-argument
+// lists are applied, including the listed order of post arguments.
+func TestSortBytesMetaArgumentsConfig(t *testing.T) {
+	input := []byte(`module "network" {
+  providers = {
+    aws = aws.east
+  }
+  version = "1.0.0"
+  name    = "net"
+  source  = "./network"
+}
+
+resource "aws_instance" "web" {
+  tags = {
+    Name = "web"
+  }
+  depends_on = [module.network]
+  ami        = "ami-123"
+  count      = 2
+}
+`)
+	want := `resource "aws_instance" "web" {
+  count = 2
+
+  ami = "ami-123"
+
+  depends_on = [module.network]
+  tags = {
+    Name = "web"
+  }
+}
+
+module "network" {
+  source  = "./network"
+  version = "1.0.0"
+  providers = {
+    aws = aws.east
+  }
+
+  name = "net"
+}
+`
+	result, err := SortBytes(input, "main.tf", &Params{
+		MetaArguments: map[string]MetaArgumentList{
+			"module":   {Pre: []string{"source", "version", "providers"}, Post: []string{}},
+			"resource": {Post: []string{"provisioner", "lifecycle", "depends_on", "tags"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SortBytes returned unexpected error: %v", err)
+	}
+	if string(result) != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", result, want)
+	}
+
+	t.Run("invalid argument name is rejected", func(t *testing.T) {
+		_, err := SortBytes(input, "main.tf", &Params{
+			MetaArguments: map[string]MetaArgumentList{"resource": {Post: []string{"tags!"}}},
+		})
+		if err == nil || !strings.Contains(err.Error(), "meta-arguments[resource]") {
+			t.Fatalf("expected meta-arguments error, got: %v", err)
+		}
+	})
+}
+
+// TestSortScope verifies the blocks and bodies scopes and the body-depth
+// limit, including in check mode.
+func TestSortScope(t *testing.T) {
+	input := `resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+  ami = "ami-123"
+  ebs_block_device {
+    volume_size = 10
+    device_name = "/dev/sdb"
+  }
+}
+
+resource "aws_instance" "app" {
+  ami = "ami-456"
+}
+`
+
+	t.Run("blocks scope keeps bodies byte-for-byte", func(t *testing.T) {
+		result, err := SortBytes([]byte(input), "main.tf", &Params{Scope: "blocks"})
+		if err != nil {
+			t.Fatalf("SortBytes returned unexpected error: %v", err)
+		}
+		want := `resource "aws_instance" "app" {
+  ami = "ami-456"
+}
+
+resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+  ami = "ami-123"
+  ebs_block_device {
+    volume_size = 10
+    device_name = "/dev/sdb"
+  }
+}
+`
+		if string(result) != want {
+			t.Errorf("unexpected output:\n%s\nwant:\n%s", result, want)
+		}
+	})
+
+	t.Run("bodies scope keeps block order", func(t *testing.T) {
+		result, err := SortBytes([]byte(input), "main.tf", &Params{Scope: "bodies"})
+		if err != nil {
+			t.Fatalf("SortBytes returned unexpected error: %v", err)
+		}
+		out := string(result)
+		if strings.Index(out, `"web"`) > strings.Index(out, `"app"`) {
+			t.Errorf("expected original block order, got:\n%s", out)
+		}
+		if strings.Index(out, "ami") > strings.Index(out, "instance_type") {
+			t.Errorf("expected sorted body, got:\n%s", out)
+		}
+	})
+
+	t.Run("body depth limits nested sorting", func(t *testing.T) {
+		result, err := SortBytes([]byte(input), "main.tf", &Params{BodyDepth: 1})
+		if err != nil {
+			t.Fatalf("SortBytes returned unexpected error: %v", err)
+		}
+		out := string(result)
+		if strings.Index(out, "ami") > strings.Index(out, "instance_type") {
+			t.Errorf("expected top-level body to be sorted, got:\n%s", out)
+		}
+		if strings.Index(out, "volume_size") > strings.Index(out, "device_name") {
+			t.Errorf("expected nested body to keep its order, got:\n%s", out)
+		}
+	})
+
+	t.Run("check honors scope", func(t *testing.T) {
+		memFS := afero.NewMemMapFs()
+		_ = memFS.MkdirAll("/scope", 0755)
+		_ = afero.WriteFile(memFS, "/scope/main.tf", []byte(`resource "aws_instance" "app" {
+  instance_type = "t3.micro"
+  ami = "ami-456"
+}
+`), 0644)
+
+		if err := NewSorter(&Params{Check: true, Scope: "blocks"}, memFS).run("/scope"); err != nil {
+			t.Errorf("expected nil with blocks scope, got: %v", err)
+		}
+		if err := NewSorter(&Params{Check: true}, memFS).run("/scope"); !errors.Is(err, ErrCheckFailed) {
+			t.Errorf("expected ErrCheckFailed with all scope, got: %v", err)
+		}
+	})
+
+	t.Run("invalid scope is rejected", func(t *testing.T) {
+		if _, err := SortBytes([]byte(input), "main.tf", &Params{Scope: "everything"}); err == nil {
+			t.Error("expected an error for an unknown scope, got nil")
+		}
+		if _, err := SortBytes([]byte(input), "main.tf", &Params{BodyDepth: -1}); err == nil {
+			t.Error("expected an error for a negative body depth, got nil")
+		}
+	})
+}
+
+// TestSortBytesDynamicBlocks verifies the dynamic-blocks placements and the
+// canonical ordering inside dynamic blocks.
+func TestSortBytesDynamicBlocks(t *testing.T) {
+	input := []byte(`resource "aws_security_group" "web" {
+  name = "web"
+
+  ingress {
+    from_port = 443
+  }
+
+  egress {
+    from_port = 0
+  }
+
+  dynamic "ingress" {
+    content {
+      from_port = ingress.value
+    }
+    labels   = []
+    iterator = ingress
+    for_each = var.ports
+  }
+}
+`)
+
+	indexes := func(t *testing.T, out string, needles ...string) []int {
+		t.Helper()
+		var idx []int
+		for _, n := range needles {
+			i := strings.Index(out, n)
+			if i == -1 {
+				t.Fatalf("expected %q in output, got:\n%s", n, out)
+			}
+			idx = append(idx, i)
+		}
+		return idx
+	}
+
+	t.Run("separate keeps dynamic under d", func(t *testing.T) {
+		result, err := SortBytes(input, "main.tf", &Params{})
+		if err != nil {
+			t.Fatalf("SortBytes returned unexpected error: %v", err)
+		}
+		idx := indexes(t, string(result), `dynamic "ingress"`, "egress {", "  ingress {")
+		if !(idx[0] < idx[1] && idx[1] < idx[2]) {
+			t.Errorf("expected dynamic, egress, ingress order:\n%s", result)
+		}
+	})
+
+	t.Run("static first", func(t *testing.T) {
+		result, err := SortBytes(input, "main.tf", &Params{DynamicBlocks: "static-first"})
+		if err != nil {
+			t.Fatalf("SortBytes returned unexpected error: %v", err)
+		}
+		idx := indexes(t, string(result), "egress {", "  ingress {", `dynamic "ingress"`)
+		if !(idx[0] < idx[1] && idx[1] < idx[2]) {
+			t.Errorf("expected egress, ingress, dynamic ingress order:\n%s", result)
+		}
+	})
+
+	t.Run("dynamic first", func(t *testing.T) {
+		result, err := SortBytes(input, "main.tf", &Params{DynamicBlocks: "dynamic-first"})
+		if err != nil {
+			t.Fatalf("SortBytes returned unexpected error: %v", err)
+		}
+		idx := indexes(t, string(result), "egress {", `dynamic "ingress"`, "  ingress {")
+		if !(idx[0] < idx[1] && idx[1] < idx[2]) {
+			t.Errorf("expected egress, dynamic ingress, ingress order:\n%s", result)
+		}
+	})
+
+	t.Run("canonical dynamic body", func(t *testing.T) {
+		result, err := SortBytes(input, "main.tf", &Params{})
+		if err != nil {
+			t.Fatalf("SortBytes returned unexpected error: %v", err)
+		}
+		idx := indexes(t, string(result), "for_each", "iterator", "labels", "content {")
+		for i := 0; i < len(idx)-1; i++ {
+			if idx[i] > idx[i+1] {
+				t.Errorf("expected for_each, iterator, labels, content order:\n%s", result)
+				break
+			}
+		}
+	})
+
+	t.Run("invalid placement is rejected", func(t *testing.T) {
+		if _, err := SortBytes(input, "main.tf", &Params{DynamicBlocks: "inline"}); err == nil {
+			t.Error("expected an error for an unknown dynamic-blocks placement, got nil")
+		}
+	})
+}
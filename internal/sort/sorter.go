@@ -2,6 +2,7 @@ package sort
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,6 +11,7 @@ import (
 	"sync"
 
 	"github.com/bmatcuk/doublestar/v4"
+	hclsyntax "github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/spf13/afero"
 )
 
@@ -27,6 +29,38 @@ type Sorter struct {
 	// HeaderPattern is a full match or a partial substring.
 	detectedHeaders   map[string]string
 	detectedHeadersMu sync.Mutex
+	// typeOrder and comparators are resolved from the block-order and
+	// block-comparators settings once per run.
+	typeOrder   blockTypeOrder
+	comparators map[string]blockComparator
+	// fileRules are the compiled file-rules used by group-by-type routing.
+	fileRules []compiledFileRule
+	// layout routes blocks ahead of the file rules for the split and merge
+	// commands; nil for sort runs.
+	layout layoutRouter
+	// metaArguments is the built-in meta argument table merged with the
+	// meta-arguments setting.
+	metaArguments map[string]map[string][]string
+	// collation is the parsed collation setting.
+	collation collation
+	// combinedSources maps the lines of the group-by-type combined file
+	// back to the input files.
+	combinedSources []combinedSource
+	// foldedComments holds the leading comments of blocks folded into a
+	// merged block by merge-blocks, keyed by the merged block. Guarded by mu.
+	foldedComments map[*hclsyntax.Block][]string
+	// report collects per-file results when a report is requested. It is
+	// shared with the per-directory sorters of a recursive run.
+	report *runReport
+	// plan collects the block moves when a plan or manifest is requested,
+	// shared like report.
+	plan *runPlan
+	// inputFiles are the files of the current sort or lint run; variable
+	// declarations are read from them.
+	inputFiles []string
+	// testProfile is set on the sorter of test and mock data files (see
+	// testFileSorter).
+	testProfile bool
 }
 
 // NewSorter constructs a Sorter for a single sort run.
@@ -41,20 +75,87 @@ func NewSorter(params *Params, fs afero.Fs) *Sorter {
 		params = &Params{}
 	}
 	paramsCopy := *params // copy, not pointer share
-	return &Sorter{
+	s := &Sorter{
 		params:          &paramsCopy,
 		fs:              fs,
 		afs:             &afero.Afero{Fs: fs},
 		linesCache:      make(map[string][]string),
 		detectedHeaders: make(map[string]string),
+		typeOrder:       newBlockTypeOrder(paramsCopy.BlockOrder),
+		comparators:     parseBlockComparators(paramsCopy.BlockComparators),
+		fileRules:       compileFileRules(paramsCopy.FileRules),
+		metaArguments:   mergeMetaArguments(paramsCopy.MetaArguments),
 	}
+	s.collation, _ = parseCollation(paramsCopy.Collation)
+	return s
+}
+
+// sortsBodyAt reports whether the body of a block at the given nesting depth
+// (1 for top-level blocks) is sorted under the scope and body-depth settings.
+func (s *Sorter) sortsBodyAt(depth int) bool {
+	if s.params.Scope == scopeBlocks {
+		return false
+	}
+	return s.params.BodyDepth == 0 || depth <= s.params.BodyDepth
+}
+
+// validateRules checks the user-configurable sorting rules. It is called
+// before any file is read so that a typo in the configuration is reported
+// instead of silently ignored.
+func (s *Sorter) validateRules() error {
+	if err := validateBlockOrdering(s.params.BlockOrder, s.params.BlockComparators); err != nil {
+		return err
+	}
+	if err := validateFileGroups(s.params.FileGroups); err != nil {
+		return err
+	}
+	if err := validateFileRules(s.params.FileRules); err != nil {
+		return err
+	}
+	if err := validateMetaArguments(s.params.MetaArguments); err != nil {
+		return err
+	}
+	if err := validateArgumentProfiles(s.params.ArgumentProfiles); err != nil {
+		return err
+	}
+	if _, err := parseCollation(s.params.Collation); err != nil {
+		return err
+	}
+	if err := validateScope(s.params.Scope, s.params.BodyDepth); err != nil {
+		return err
+	}
+	if err := validateDynamicBlocks(s.params.DynamicBlocks); err != nil {
+		return err
+	}
+	if err := validateListPaths(s.params.ListPaths); err != nil {
+		return err
+	}
+	if err := validateDuplicateBlocks(s.params.DuplicateBlocks); err != nil {
+		return err
+	}
+	if err := validateVarsOrder(s.params.VarsOrder); err != nil {
+		return err
+	}
+	if s.params.MergeBlocks && s.params.Scope == scopeBlocks {
+		return fmt.Errorf("the merge-blocks flag conflicts with scope %q", scopeBlocks)
+	}
+	if err := validateReportFormat(s.params.ReportFormat); err != nil {
+		return err
+	}
+	if err := validateColor(s.params.Color); err != nil {
+		return err
+	}
+	if err := validatePlanFormat(s.params.Plan); err != nil {
+		return err
+	}
+	return nil
 }
 
 // run is the internal entry point for a sort execution.
 func (s *Sorter) run(target string) error {
 	// 1. Validate flag combinations
-	if s.params.Inline && (s.params.GroupByType || s.params.OutputDir != "") {
-		return fmt.Errorf("the inline flag conflicts with the group-by-type and output-dir flags")
+	if s.params.Inline && s.params.OutputDir != "" {
+		return fmt.Errorf("the inline flag conflicts with the output-dir flag")
 	}
 	if s.params.KeepHeader && (!s.params.HasHeader || s.params.HeaderPattern == "") {
 		return fmt.Errorf("keep-header requires has-header=true and a non-empty header-pattern")
@@ -68,6 +169,19 @@ func (s *Sorter) run(target string) error {
 	if s.params.Diff && s.params.Inline {
 		return fmt.Errorf("the diff flag conflicts with the inline flag")
 	}
+	if s.params.Summary && s.params.Diff {
+		return fmt.Errorf("the summary flag conflicts with the diff flag")
+	}
+	if s.params.Plan != "" && (s.params.Diff || s.params.Summary) {
+		return fmt.Errorf("the plan flag conflicts with the diff and summary flags")
+	}
+	if s.params.Manifest && s.params.OutputDir == "" {
+		return fmt.Errorf("the manifest flag requires the output-dir flag")
+	}
+	if s.params.ReportFormat != "" && s.params.ReportFormat != reportText && s.params.ReportFile == "" &&
+		(s.params.Diff || s.params.Summary || s.params.Plan != "" || (!s.params.Check && !s.params.Inline && s.params.OutputDir == "")) {
+		return fmt.Errorf("report-format %q needs report-file when sorted files, diffs or summaries are printed to stdout", s.params.ReportFormat)
+	}
 
 	// 1a. Validate exclude glob patterns.
 	for _, p := range s.params.Excludes {
@@ -76,19 +190,43 @@ func (s *Sorter) run(target string) error {
 		}
 	}
 
+	// 1b. Validate the configurable sorting rules.
+	if err := s.validateRules(); err != nil {
+		return err
+	}
+
+	if s.reportEnabled() {
+		s.report = &runReport{}
+	}
+	if s.planEnabled() {
+		s.plan = &runPlan{}
+	}
+
 	// 2. Handle recursive mode: process each directory independently.
+	var err error
 	if s.params.Recursive {
-		info, err := s.getPathInfo(target)
-		if err != nil {
-			return err
+		info, statErr := s.getPathInfo(target)
+		if statErr != nil {
+			return statErr
 		}
 		if !info.IsDir() {
 			return fmt.Errorf("the recursive flag requires a directory target")
 		}
-		return s.runRecursive(target)
+		err = s.runRecursive(target)
+	} else {
+		err = s.runSingle(target)
 	}
 
-	return s.runSingle(target)
+	// 3. Write the report for completed runs, including failed checks.
+	if err == nil || errors.Is(err, ErrCheckFailed) {
+		if reportErr := s.writeReport(); reportErr != nil {
+			return reportErr
+		}
+		if planErr := s.writePlan(); planErr != nil {
+			return planErr
+		}
+	}
+	return err
 }
 
 // runSingle processes a single target (file or directory).
@@ -105,6 +243,16 @@ func (s *Sorter) runSingle(target string) error {
 		return fmt.Errorf("could not sort files: %w", err)
 	}
 
+	if err := s.recordResults(target, files, sortedFiles); err != nil {
+		return err
+	}
+	if err := s.printSummary(target, files, sortedFiles); err != nil {
+		return err
+	}
+	if err := s.recordPlan(target, files, sortedFiles); err != nil {
+		return err
+	}
+
 	// Diff mode — show unified diff of changes
 	if s.params.Diff {
 		return s.runDiffMode(target, files, sortedFiles)
@@ -115,6 +263,14 @@ func (s *Sorter) runSingle(target string) error {
 		return s.runCheckMode(target, files, sortedFiles)
 	}
 
+	// Grouped inline mode replaces the files of the target directory.
+	if s.params.Inline && s.params.GroupByType {
+		if !s.groupsInPlace(target) {
+			return fmt.Errorf("the inline flag with group-by-type requires a directory target")
+		}
+		return s.writeGroupedInPlace(target, files, sortedFiles)
+	}
+
 	// Resolve output dir for inline mode
 	if s.params.Inline {
 		s.params.OutputDir, err = s.getDirectory(target)
@@ -128,7 +284,7 @@ func (s *Sorter) runSingle(target string) error {
 		if err := s.writeFiles(sortedFiles); err != nil {
 			return fmt.Errorf("could not write files: %w", err)
 		}
-	} else {
+	} else if !s.params.Summary && s.params.Plan == "" {
 		for _, body := range sortedFiles {
 			fmt.Print(string(body))
 		}
@@ -181,16 +337,37 @@ func (s *Sorter) runCheckMode(target string, inputFiles []string, sortedFiles ma
 		}
 	}
 
+	// Files whose blocks all move elsewhere would be deleted.
+	removedFiles, err := s.removedFiles(target, inputFiles, sortedFiles)
+	if err != nil {
+		return fmt.Errorf("check: %w", err)
+	}
+	removed := map[string]bool{}
+	for _, f := range removedFiles {
+		absPath, absErr := filepath.Abs(f)
+		if absErr != nil {
+			absPath = f
+		}
+		changed = append(changed, absPath)
+		removed[absPath] = true
+	}
+
 	if len(changed) == 0 {
 		return nil
 	}
 
 	gosort.Strings(changed) // deterministic output order
-	fmt.Fprintln(os.Stderr, "The following files would be changed by tforganize sort:")
-	for _, f := range changed {
-		fmt.Fprintf(os.Stderr, "  - %s\n", f)
+	if s.params.ReportFormat == "" || s.params.ReportFormat == reportText {
+		fmt.Fprintln(os.Stderr, "The following files would be changed by tforganize sort:")
+		for _, f := range changed {
+			if removed[f] {
+				fmt.Fprintf(os.Stderr, "  - %s (removed)\n", f)
+			} else {
+				fmt.Fprintf(os.Stderr, "  - %s\n", f)
+			}
+		}
+		fmt.Fprintln(os.Stderr, "\nRun 'tforganize sort <target>' to sort these files.")
 	}
-	fmt.Fprintln(os.Stderr, "\nRun 'tforganize sort <target>' to sort these files.")
 
 	return fmt.Errorf("%w: %s", ErrCheckFailed, strings.Join(changed, ", "))
 }
@@ -199,7 +376,7 @@ func (s *Sorter) runCheckMode(target string, inputFiles []string, sortedFiles ma
 // the full path of the original source file.
 //
 // When --group-by-type is set, sorted output keys are canonical group filenames
-// (e.g. "variables.tf"). The original file is resolved as filepath.Join(target, outputKey).
+// (e.g. "variables.tf", or the names configured via file-groups). The original file is resolved as filepath.Join(target, outputKey).
 //
 // Otherwise, the original file is found by matching outputKey against the
 // basename of each path in inputFiles.
@@ -258,10 +435,21 @@ func (s *Sorter) runRecursive(target string) error {
 		}
 
 		dirSorter := NewSorter(&dirParams, s.fs)
+		dirSorter.report = s.report
+		dirSorter.plan = s.plan
 		sortedFiles, sortErr := dirSorter.sortFiles(files)
 		if sortErr != nil {
 			return fmt.Errorf("could not sort files in %s: %w", path, sortErr)
 		}
+		if recordErr := dirSorter.recordResults(path, files, sortedFiles); recordErr != nil {
+			return recordErr
+		}
+		if summaryErr := dirSorter.printSummary(path, files, sortedFiles); summaryErr != nil {
+			return summaryErr
+		}
+		if planErr := dirSorter.recordPlan(path, files, sortedFiles); planErr != nil {
+			return planErr
+		}
 
 		if dirParams.Check {
 			if checkErr := dirSorter.runCheckMode(path, files, sortedFiles); checkErr != nil {
@@ -272,11 +460,15 @@ func (s *Sorter) runRecursive(target string) error {
 			return nil
 		}
 
-		if dirParams.OutputDir != "" {
+		if dirParams.Inline && dirParams.GroupByType {
+			if writeErr := dirSorter.writeGroupedInPlace(path, files, sortedFiles); writeErr != nil {
+				return fmt.Errorf("could not write files in %s: %w", path, writeErr)
+			}
+		} else if dirParams.OutputDir != "" {
 			if writeErr := dirSorter.writeFiles(sortedFiles); writeErr != nil {
 				return fmt.Errorf("could not write files in %s: %w", path, writeErr)
 			}
-		} else {
+		} else if !dirParams.Summary && dirParams.Plan == "" {
 			for _, body := range sortedFiles {
 				fmt.Print(string(body))
 			}
@@ -291,6 +483,58 @@ func (s *Sorter) runRecursive(target string) error {
 	return firstCheckErr
 }
 
+// writeGroupedInPlace replaces the files of dir with the group-by-type
+// output: it writes the grouped files, deletes the input files whose blocks
+// all moved to other files and lists every created, modified and removed
+// file on stderr.
+func (s *Sorter) writeGroupedInPlace(dir string, inputFiles []string, sortedFiles map[string][]byte) error {
+	if err := s.checkInPlaceOutputs(dir, inputFiles, sortedFiles); err != nil {
+		return err
+	}
+	removed, err := s.emptiedFiles(inputFiles, sortedFiles)
+	if err != nil {
+		return err
+	}
+	if err := checkRemovable(removed); err != nil {
+		return err
+	}
+
+	changes := map[string]string{} // path to "Created", "Modified" or "Removed"
+	for name, content := range sortedFiles {
+		path := filepath.Join(dir, name)
+		original, err := s.afs.ReadFile(path)
+		switch {
+		case os.IsNotExist(err):
+			changes[path] = "Created"
+		case err != nil:
+			return fmt.Errorf("could not read %s: %w", path, err)
+		case !bytes.Equal(original, content):
+			changes[path] = "Modified"
+		}
+		if err := s.writeFile(path, content); err != nil {
+			return fmt.Errorf("could not write %s: %w", path, err)
+		}
+	}
+	if err := s.removeFiles(removed); err != nil {
+		return err
+	}
+	for _, f := range removed {
+		changes[f] = "Removed"
+	}
+
+	if s.params.ReportFormat == "" || s.params.ReportFormat == reportText {
+		paths := make([]string, 0, len(changes))
+		for path := range changes {
+			paths = append(paths, path)
+		}
+		gosort.Strings(paths)
+		for _, path := range paths {
+			fmt.Fprintf(os.Stderr, "%s %s\n", changes[path], path)
+		}
+	}
+	return nil
+}
+
 // runDiffMode prints a unified diff for each file that would change and
 // optionally returns ErrCheckFailed when combined with --check.
 func (s *Sorter) runDiffMode(target string, inputFiles []string, sortedFiles map[string][]byte) error {
@@ -313,7 +557,7 @@ func (s *Sorter) runDiffMode(target string, inputFiles []string, sortedFiles map
 			if absErr != nil {
 				absPath = outputKey
 			}
-			diff := unifiedDiff(absPath, absPath, "", string(sortedBytes))
+			diff := s.renderDiff(absPath, absPath, "", string(sortedBytes))
 			if diff != "" {
 				fmt.Print(diff)
 				changed = append(changed, absPath)
@@ -324,7 +568,7 @@ func (s *Sorter) runDiffMode(target string, inputFiles []string, sortedFiles map
 		originalBytes, err := s.afs.ReadFile(originalPath)
 		if err != nil {
 			if os.IsNotExist(err) {
-				diff := unifiedDiff(originalPath, originalPath, "", string(sortedBytes))
+				diff := s.renderDiff(originalPath, originalPath, "", string(sortedBytes))
 				if diff != "" {
 					fmt.Print(diff)
 					changed = append(changed, originalPath)
@@ -334,13 +578,27 @@ func (s *Sorter) runDiffMode(target string, inputFiles []string, sortedFiles map
 			return fmt.Errorf("diff: could not read original file %s: %w", originalPath, err)
 		}
 
-		diff := unifiedDiff(originalPath, originalPath, string(originalBytes), string(sortedBytes))
+		diff := s.renderDiff(originalPath, originalPath, string(originalBytes), string(sortedBytes))
 		if diff != "" {
 			fmt.Print(diff)
 			changed = append(changed, originalPath)
 		}
 	}
 
+	// Files whose blocks all move elsewhere are shown as deleted.
+	removed, err := s.removedFiles(target, inputFiles, sortedFiles)
+	if err != nil {
+		return fmt.Errorf("diff: %w", err)
+	}
+	for _, f := range removed {
+		originalBytes, err := s.afs.ReadFile(f)
+		if err != nil {
+			return fmt.Errorf("diff: could not read original file %s: %w", f, err)
+		}
+		fmt.Print(s.renderDiff(f, os.DevNull, string(originalBytes), ""))
+		changed = append(changed, f)
+	}
+
 	if s.params.Check && len(changed) > 0 {
 		return fmt.Errorf("%w: %s", ErrCheckFailed, strings.Join(changed, ", "))
 	}
@@ -0,0 +1,453 @@
+package sort
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	gosort "sort"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	hclsyntax "github.com/hashicorp/hcl/v2/hclsyntax"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxSummaryKeys is the number of argument names listed in a
+// "reordered" summary line before it is shortened.
+const maxSummaryKeys = 6
+
+// summaryFile is a parsed file on either side of a sort run.
+type summaryFile struct {
+	path string
+	src  []byte
+	body *hclsyntax.Body
+}
+
+// summaryBlock is a top-level block located in a summaryFile.
+type summaryBlock struct {
+	block *hclsyntax.Block
+	file  *summaryFile
+}
+
+// fileStat counts the changes made to one file.
+type fileStat struct {
+	movedIn, movedOut, moved, reordered int
+	created, changed                    bool
+}
+
+// blockSummary is the semantic summary of sorting one module.
+type blockSummary struct {
+	moves    []string
+	bodies   []string
+	comments int
+	stats    map[string]*fileStat
+}
+
+// summarize compares the input files with the sorted output block by block
+// and describes the changes: blocks that moved within or between files,
+// bodies whose arguments were reordered and comments that were removed.
+func (s *Sorter) summarize(target string, inputFiles []string, sortedFiles map[string][]byte) (*blockSummary, error) {
+	log.WithField("target", target).Traceln("Starting summarize")
+
+	before, after, err := s.parseRunFiles(target, inputFiles, sortedFiles)
+	if err != nil {
+		return nil, fmt.Errorf("summary: %w", err)
+	}
+
+	summary := &blockSummary{stats: map[string]*fileStat{}}
+	for _, f := range after {
+		original, err := s.afs.ReadFile(f.path)
+		switch {
+		case os.IsNotExist(err):
+			summary.stat(f.path).created = true
+		case err != nil:
+			return nil, fmt.Errorf("summary: could not read %s: %w", f.path, err)
+		case !bytes.Equal(original, f.src):
+			summary.stat(f.path).changed = true
+		}
+	}
+
+	beforeBlocks, afterBlocks := summaryBlocks(before), summaryBlocks(after)
+	addresses := make([]string, 0, len(beforeBlocks))
+	for address := range beforeBlocks {
+		addresses = append(addresses, address)
+	}
+	gosort.Strings(addresses)
+
+	// Blocks that stay in their file, per file, to find the ones that moved
+	// within it.
+	staying := map[string][][2]summaryBlock{}
+	for _, address := range addresses {
+		olds, news := beforeBlocks[address], afterBlocks[address]
+		for i, old := range olds {
+			name := summaryName(address, i, max(len(olds), len(news)))
+			if i >= len(news) {
+				if s.params.MergeBlocks && len(news) > 0 {
+					summary.moves = append(summary.moves, fmt.Sprintf("%s merged into %s", name, blockLocation(news[0])))
+				} else {
+					summary.moves = append(summary.moves, fmt.Sprintf("%s removed", name))
+				}
+				summary.stat(old.file.path).movedOut++
+				continue
+			}
+			updated := news[i]
+			if old.file.path != updated.file.path {
+				summary.moves = append(summary.moves, fmt.Sprintf("%s moved from %s to %s", name, blockLocation(old), blockLocation(updated)))
+				summary.stat(old.file.path).movedOut++
+				summary.stat(updated.file.path).movedIn++
+			} else {
+				staying[old.file.path] = append(staying[old.file.path], [2]summaryBlock{old, updated})
+			}
+			if changes := compareBodies(name, old, updated); len(changes) > 0 {
+				summary.bodies = append(summary.bodies, changes...)
+				summary.stat(updated.file.path).reordered++
+			}
+		}
+	}
+
+	stayingPaths := make([]string, 0, len(staying))
+	for path := range staying {
+		stayingPaths = append(stayingPaths, path)
+	}
+	gosort.Strings(stayingPaths)
+	for _, path := range stayingPaths {
+		pairs := staying[path]
+		// Rank the blocks in their new order by their old position; those
+		// outside the longest increasing run are the ones that moved.
+		gosort.Slice(pairs, func(i, j int) bool {
+			return pairs[i][1].block.TypeRange.Start.Byte < pairs[j][1].block.TypeRange.Start.Byte
+		})
+		ranks := make([]int, len(pairs))
+		for i, pair := range pairs {
+			ranks[i] = pair[0].block.TypeRange.Start.Byte
+		}
+		for _, i := range outOfOrder(ranks, nil) {
+			old, updated := pairs[i][0], pairs[i][1]
+			address := summaryAddress(old.block)
+			name := summaryName(address, indexOf(beforeBlocks[address], old), len(beforeBlocks[address]))
+			summary.moves = append(summary.moves, fmt.Sprintf("%s moved from %s to %s", name, blockLocation(old), blockLocation(updated)))
+			summary.stat(path).moved++
+		}
+	}
+
+	for _, f := range before {
+		summary.comments += countComments(f.src, f.path)
+	}
+	for _, f := range after {
+		summary.comments -= countComments(f.src, f.path)
+	}
+
+	return summary, nil
+}
+
+// parseRunFiles parses the input files and the sorted output of a run. The
+// sorted files are named after the file they replace, in name order.
+// Variable definitions files are left out.
+func (s *Sorter) parseRunFiles(target string, inputFiles []string, sortedFiles map[string][]byte) ([]*summaryFile, []*summaryFile, error) {
+	var before, after []*summaryFile
+	for _, f := range inputFiles {
+		if isVarsFile(f) {
+			continue
+		}
+		src, err := s.afs.ReadFile(f)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not read %s: %w", f, err)
+		}
+		body, err := s.parseRunFile(src, f)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not parse %s: %w", f, err)
+		}
+		before = append(before, &summaryFile{path: f, src: src, body: body})
+	}
+
+	keys := make([]string, 0, len(sortedFiles))
+	for key := range sortedFiles {
+		keys = append(keys, key)
+	}
+	gosort.Strings(keys)
+	for _, key := range keys {
+		if isVarsFile(key) {
+			continue
+		}
+		path, err := s.resolveOriginalPath(target, inputFiles, key)
+		if err != nil {
+			path = filepath.Join(target, key)
+		}
+		body, err := s.parseRunFile(sortedFiles[key], path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not parse sorted %s: %w", key, err)
+		}
+		after = append(after, &summaryFile{path: path, src: sortedFiles[key], body: body})
+	}
+	return before, after, nil
+}
+
+// parseRunFile parses one file of a run, in the JSON or the native syntax.
+func (s *Sorter) parseRunFile(src []byte, path string) (*hclsyntax.Body, error) {
+	if isJSONFile(path) {
+		return jsonSummaryBody(src, path)
+	}
+	return s.parseHclBytes(src, path)
+}
+
+// printSummary prints the summary of sorting a module to stdout when the
+// summary setting is on. It must run before files are written.
+func (s *Sorter) printSummary(target string, inputFiles []string, sortedFiles map[string][]byte) error {
+	if !s.params.Summary {
+		return nil
+	}
+	summary, err := s.summarize(target, inputFiles, sortedFiles)
+	if err != nil {
+		return err
+	}
+	return summary.write(os.Stdout)
+}
+
+// stat returns the counters of path, creating them on first use.
+func (bs *blockSummary) stat(path string) *fileStat {
+	if bs.stats[path] == nil {
+		bs.stats[path] = &fileStat{}
+	}
+	return bs.stats[path]
+}
+
+// write prints the summary lines followed by one stat line per changed
+// file. Nothing is printed for a module without changes.
+func (bs *blockSummary) write(w io.Writer) error {
+	var lines []string
+	lines = append(lines, bs.moves...)
+	lines = append(lines, bs.bodies...)
+	if bs.comments > 0 {
+		lines = append(lines, fmt.Sprintf("%s removed", plural(bs.comments, "comment", "comments")))
+	}
+
+	var paths []string
+	for path, stat := range bs.stats {
+		if stat.describe() != "" {
+			paths = append(paths, path)
+		}
+	}
+	gosort.Strings(paths)
+	if len(lines) == 0 && len(paths) == 0 {
+		return nil
+	}
+
+	width := 0
+	for _, path := range paths {
+		width = max(width, len(reportPath(path)))
+	}
+	if len(lines) > 0 && len(paths) > 0 {
+		lines = append(lines, "")
+	}
+	for _, path := range paths {
+		lines = append(lines, fmt.Sprintf(" %-*s | %s", width, reportPath(path), bs.stats[path].describe()))
+	}
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+// describe renders the counters as the tail of a stat line, or "" when the
+// file is unchanged.
+func (fs *fileStat) describe() string {
+	var parts []string
+	if fs.created {
+		parts = append(parts, "created")
+	}
+	if fs.moved > 0 {
+		parts = append(parts, plural(fs.moved, "block", "blocks")+" moved")
+	}
+	if fs.movedIn > 0 {
+		parts = append(parts, plural(fs.movedIn, "block", "blocks")+" moved in")
+	}
+	if fs.movedOut > 0 {
+		parts = append(parts, plural(fs.movedOut, "block", "blocks")+" moved out")
+	}
+	if fs.reordered > 0 {
+		parts = append(parts, plural(fs.reordered, "body", "bodies")+" reordered")
+	}
+	if len(parts) == 0 && fs.changed {
+		parts = append(parts, "reformatted")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// summaryBlocks indexes the top-level blocks of files by address, in file
+// and source order.
+func summaryBlocks(files []*summaryFile) map[string][]summaryBlock {
+	blocks := map[string][]summaryBlock{}
+	for _, f := range files {
+		for _, block := range f.body.Blocks {
+			address := summaryAddress(block)
+			blocks[address] = append(blocks[address], summaryBlock{block: block, file: f})
+		}
+	}
+	return blocks
+}
+
+// summaryAddress names a block by its type and labels joined with dots,
+// e.g. resource.aws_iam_role.app.
+func summaryAddress(block *hclsyntax.Block) string {
+	return strings.Join(append([]string{block.Type}, block.Labels...), ".")
+}
+
+// summaryName is the address of the i-th block with that address, numbered
+// only when there are several.
+func summaryName(address string, i, count int) string {
+	if count > 1 {
+		return fmt.Sprintf("%s #%d", address, i+1)
+	}
+	return address
+}
+
+// blockLocation returns the file:line of a block.
+func blockLocation(b summaryBlock) string {
+	return fmt.Sprintf("%s:%d", reportPath(b.file.path), b.block.TypeRange.Start.Line)
+}
+
+// indexOf returns the position of b among blocks, or 0 when it is not
+// found.
+func indexOf(blocks []summaryBlock, b summaryBlock) int {
+	for i, candidate := range blocks {
+		if candidate.block == b.block {
+			return i
+		}
+	}
+	return 0
+}
+
+// compareBodies describes how the body of a block changed: its arguments
+// and nested blocks reordered, or argument values whose text changed other
+// than in whitespace (such as sorted tags). Nested blocks are compared
+// recursively.
+func compareBodies(name string, old, updated summaryBlock) []string {
+	var changes []string
+
+	oldKeys, newKeys := bodyKeys(old.block.Body), bodyKeys(updated.block.Body)
+	if sameKeys(oldKeys, newKeys) && !slices.Equal(oldKeys, newKeys) {
+		listed := newKeys
+		suffix := ""
+		if len(listed) > maxSummaryKeys {
+			listed, suffix = listed[:maxSummaryKeys], ", ..."
+		}
+		changes = append(changes, fmt.Sprintf("%s: arguments reordered (%s%s)", name, strings.Join(listed, ", "), suffix))
+	}
+
+	for _, attrName := range sortedAttributeNames(old.block.Body.Attributes) {
+		newAttr, ok := updated.block.Body.Attributes[attrName]
+		if !ok {
+			continue
+		}
+		oldText := old.file.valueText(old.block.Body.Attributes[attrName].Expr.Range())
+		if oldText != updated.file.valueText(newAttr.Expr.Range()) {
+			changes = append(changes, fmt.Sprintf("%s: value of %s reordered", name, attrName))
+		}
+	}
+
+	// Nested blocks are paired by key in order.
+	nested := map[string][]*hclsyntax.Block{}
+	for _, child := range updated.block.Body.Blocks {
+		nested[formatBlockKey(child)] = append(nested[formatBlockKey(child)], child)
+	}
+	seen := map[string]int{}
+	for _, child := range old.block.Body.Blocks {
+		key := formatBlockKey(child)
+		i := seen[key]
+		seen[key]++
+		if i >= len(nested[key]) {
+			continue
+		}
+		childName := name + "." + strings.ReplaceAll(key, " ", ".")
+		changes = append(changes, compareBodies(childName,
+			summaryBlock{block: child, file: old.file},
+			summaryBlock{block: nested[key][i], file: updated.file})...)
+	}
+	return changes
+}
+
+// bodyKeys lists the attribute names and nested block keys of body in
+// source order.
+func bodyKeys(body *hclsyntax.Body) []string {
+	type item struct {
+		key  string
+		byte int
+	}
+	var items []item
+	for _, attribute := range body.Attributes {
+		items = append(items, item{attribute.Name, attribute.SrcRange.Start.Byte})
+	}
+	for _, child := range body.Blocks {
+		items = append(items, item{formatBlockKey(child), child.TypeRange.Start.Byte})
+	}
+	gosort.Slice(items, func(i, j int) bool { return items[i].byte < items[j].byte })
+
+	keys := make([]string, len(items))
+	for i, it := range items {
+		keys[i] = it.key
+	}
+	return keys
+}
+
+// sameKeys reports whether a and b hold the same keys in any order.
+func sameKeys(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := map[string]int{}
+	for _, key := range a {
+		counts[key]++
+	}
+	for _, key := range b {
+		counts[key]--
+		if counts[key] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// valueText returns the source text of the value at rng in a form that only
+// changes when the value does: compact JSON in a JSON file, and the text
+// with whitespace collapsed otherwise.
+func (f *summaryFile) valueText(rng hcl.Range) string {
+	text := normalizedText(f.src, rng)
+	if isJSONFile(f.path) {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, []byte(text)); err == nil {
+			return buf.String()
+		}
+	}
+	return text
+}
+
+// normalizedText returns the source text of rng with whitespace collapsed.
+func normalizedText(src []byte, rng hcl.Range) string {
+	if rng.Start.Byte < 0 || rng.End.Byte > len(src) || rng.Start.Byte > rng.End.Byte {
+		return ""
+	}
+	return collapseSpaces(string(src[rng.Start.Byte:rng.End.Byte]))
+}
+
+// countComments counts the comments in src.
+func countComments(src []byte, filename string) int {
+	tokens, _ := hclsyntax.LexConfig(src, filename, hcl.InitialPos)
+	count := 0
+	for _, token := range tokens {
+		if token.Type == hclsyntax.TokenComment {
+			count++
+		}
+	}
+	return count
+}
+
+// plural formats n with the singular or plural noun.
+func plural(n int, singular, pluralForm string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, pluralForm)
+}
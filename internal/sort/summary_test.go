@@ -0,0 +1,181 @@
+package sort
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+// unsortedSummaryMain has a variable out of order with its arguments
+// reordered and a commented resource.
+const unsortedSummaryMain = `variable "region" {
+  default = "x"
+  type    = string
+}
+
+# The application role.
+resource "aws_iam_role" "app" {
+  name = "app"
+}
+
+variable "a" {
+  type = string
+}
+`
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name   string
+		params Params
+		want   string
+	}{
+		{
+			name:   "per file",
+			params: Params{},
+			want: `variable.a moved from /sum/main.tf:11 to /sum/main.tf:1
+variable.region: arguments reordered (type, default)
+
+ /sum/main.tf | 1 block moved, 1 body reordered
+`,
+		},
+		{
+			name:   "group-by-type without comments",
+			params: Params{GroupByType: true, RemoveComments: true},
+			want: `variable.a moved from /sum/main.tf:11 to /sum/variables.tf:1
+variable.region moved from /sum/main.tf:1 to /sum/variables.tf:5
+variable.region: arguments reordered (type, default)
+1 comment removed
+
+ /sum/main.tf      | 2 blocks moved out
+ /sum/variables.tf | created, 2 blocks moved in, 1 body reordered
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memFS := afero.NewMemMapFs()
+			_ = memFS.MkdirAll("/sum/child", 0755)
+			_ = afero.WriteFile(memFS, "/sum/main.tf", []byte(unsortedSummaryMain), 0644)
+			_ = afero.WriteFile(memFS, "/sum/outputs.tf", []byte("output \"id\" {\n  value = 1\n}\n"), 0644)
+			_ = afero.WriteFile(memFS, "/sum/child/main.tf", []byte("locals {\n  b = 1\n}\n\nterraform {}\n"), 0644)
+			params := tt.params
+			s := NewSorter(&params, memFS)
+			files := []string{"/sum/main.tf", "/sum/outputs.tf"}
+			sortedFiles, err := s.sortFiles(files)
+			if err != nil {
+				t.Fatalf("sortFiles() error: %v", err)
+			}
+			summary, err := s.summarize("/sum", files, sortedFiles)
+			if err != nil {
+				t.Fatalf("summarize() error: %v", err)
+			}
+			var buf strings.Builder
+			if err := summary.write(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("summary =\n%s\nwant:\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeBodyValues(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/sum", 0755)
+	_ = afero.WriteFile(memFS, "/sum/main.tf", []byte(`resource "aws_instance" "web" {
+  ami  = "x"
+  tags = { b = 1, a = 2 }
+
+  lifecycle {
+    prevent_destroy       = true
+    create_before_destroy = true
+  }
+}
+`), 0644)
+
+	s := NewSorter(&Params{SortObjectKeys: true}, memFS)
+	sortedFiles, err := s.sortFiles([]string{"/sum/main.tf"})
+	if err != nil {
+		t.Fatalf("sortFiles() error: %v", err)
+	}
+	summary, err := s.summarize("/sum", []string{"/sum/main.tf"}, sortedFiles)
+	if err != nil {
+		t.Fatalf("summarize() error: %v", err)
+	}
+
+	want := []string{
+		"resource.aws_instance.web: value of tags reordered",
+		"resource.aws_instance.web.lifecycle: arguments reordered (create_before_destroy, prevent_destroy)",
+	}
+	if strings.Join(summary.bodies, "\n") != strings.Join(want, "\n") {
+		t.Errorf("bodies =\n%s\nwant:\n%s", strings.Join(summary.bodies, "\n"), strings.Join(want, "\n"))
+	}
+	if len(summary.moves) != 0 {
+		t.Errorf("expected no moves, got %v", summary.moves)
+	}
+}
+
+// unsortedSummaryJSON is a Terraform JSON file with its resources out of
+// order and the arguments of one of them reordered.
+const unsortedSummaryJSON = `{
+  "resource": {
+    "aws_s3_bucket": {
+      "b": {"tags": {"z": "1", "a": "2"}, "bucket": "b"},
+      "a": {"bucket": "a"}
+    }
+  }
+}
+`
+
+func TestSummarizeJSON(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/sum", 0755)
+	_ = afero.WriteFile(memFS, "/sum/main.tf.json", []byte(unsortedSummaryJSON), 0644)
+
+	s := NewSorter(&Params{}, memFS)
+	sortedFiles, err := s.sortFiles([]string{"/sum/main.tf.json"})
+	if err != nil {
+		t.Fatalf("sortFiles() error: %v", err)
+	}
+	summary, err := s.summarize("/sum", []string{"/sum/main.tf.json"}, sortedFiles)
+	if err != nil {
+		t.Fatalf("summarize() error: %v", err)
+	}
+	var buf strings.Builder
+	if err := summary.write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `resource.aws_s3_bucket.b moved from /sum/main.tf.json:4 to /sum/main.tf.json:7
+resource.aws_s3_bucket.b: arguments reordered (bucket, tags)
+
+ /sum/main.tf.json | 1 block moved, 1 body reordered
+`
+	if buf.String() != want {
+		t.Errorf("summary =\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestSummaryInlineRecursive(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/sum/child", 0755)
+	_ = afero.WriteFile(memFS, "/sum/main.tf", []byte(unsortedSummaryMain), 0644)
+	_ = afero.WriteFile(memFS, "/sum/outputs.tf", []byte("output \"id\" {\n  value = 1\n}\n"), 0644)
+	_ = afero.WriteFile(memFS, "/sum/child/main.tf", []byte("locals {\n  b = 1\n}\n\nterraform {}\n"), 0644)
+	s := NewSorter(&Params{Summary: true, Inline: true, Recursive: true, MergeBlocks: true}, memFS)
+	if err := s.run("/sum"); err != nil {
+		t.Fatalf("run() error: %v", err)
+	}
+	sorted, _ := afero.ReadFile(memFS, "/sum/main.tf")
+	if !strings.HasPrefix(string(sorted), `variable "a"`) {
+		t.Errorf("expected main.tf to be sorted in place, got:\n%s", sorted)
+	}
+
+	if err := NewSorter(&Params{Summary: true, Diff: true}, memFS).run("/sum"); err == nil ||
+		!strings.Contains(err.Error(), "the summary flag conflicts with the diff flag") {
+		t.Errorf("expected summary/diff conflict, got: %v", err)
+	}
+}
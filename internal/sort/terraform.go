@@ -1,6 +1,11 @@
 package sort
 
 import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
 	hclsyntax "github.com/hashicorp/hcl/v2/hclsyntax"
 	log "github.com/sirupsen/logrus"
 )
@@ -8,6 +13,29 @@ import (
 const (
 	defaultFileGroup         = "main.tf"
 	defaultBlockTypePriority = 6 // between module and import
+	// defaultFileGroupKey is the file-groups key that overrides defaultFileGroup.
+	defaultFileGroupKey = "default"
+)
+
+// Scopes accepted by the scope setting.
+const (
+	scopeAll    = "all"
+	scopeBlocks = "blocks"
+	scopeBodies = "bodies"
+)
+
+// Placements accepted by the dynamic-blocks setting.
+const (
+	dynamicSeparate     = "separate"
+	dynamicStaticFirst  = "static-first"
+	dynamicDynamicFirst = "dynamic-first"
+)
+
+// Comparator names accepted by the block-comparators setting.
+const (
+	comparatorAlphabetical     = "alphabetical"
+	comparatorPreserveOriginal = "preserve-original"
+	comparatorLabelPrefix      = "label:"
 )
 
 // metaArguments is a map of block types to meta arguments.
@@ -40,7 +68,8 @@ var (
 
 	// fileGroups maps block types to the canonical output file name used when
 	// --group-by-type is enabled. Block types not listed here fall back to
-	// defaultFileGroup ("main.tf").
+	// defaultFileGroup ("main.tf"). Both can be overridden with the
+	// file-groups setting (see getFileGroup).
 	//
 	// Default file name mapping:
 	//   check     → checks.tf
@@ -73,9 +102,11 @@ var (
 			"pre":  []string{"count", "for_each", "provider"},
 			"post": []string{"provisioner", "depends_on"},
 		},
+		// dynamic block: https://developer.hashicorp.com/terraform/language/expressions/dynamic-blocks
+		// for_each, iterator and labels configure the generated blocks; content comes last.
 		"dynamic": {
-			"pre":  []string{"for_each"},
-			"post": []string{},
+			"pre":  []string{"for_each", "iterator", "labels"},
+			"post": []string{"content"},
 		},
 		// import block: https://developer.hashicorp.com/terraform/language/import
 		// Required: to, id. Optional: provider.
@@ -138,15 +169,258 @@ func getBlockTypePriority(blockType string) int {
 	return defaultBlockTypePriority
 }
 
-// getMetaArguments returns the meta arguments that should be first and last inside of a block
+// validateScope checks the scope and body-depth settings.
+func validateScope(scope string, bodyDepth int) error {
+	switch scope {
+	case "", scopeAll, scopeBlocks, scopeBodies:
+	default:
+		return fmt.Errorf("unknown scope %q (expected %s, %s or %s)", scope, scopeAll, scopeBlocks, scopeBodies)
+	}
+	if bodyDepth < 0 {
+		return fmt.Errorf("body-depth must not be negative, got %d", bodyDepth)
+	}
+	return nil
+}
+
+// validateDynamicBlocks checks the dynamic-blocks setting.
+func validateDynamicBlocks(placement string) error {
+	switch placement {
+	case "", dynamicSeparate, dynamicStaticFirst, dynamicDynamicFirst:
+		return nil
+	}
+	return fmt.Errorf("unknown dynamic-blocks placement %q (expected %s, %s or %s)",
+		placement, dynamicSeparate, dynamicStaticFirst, dynamicDynamicFirst)
+}
+
+// getFileGroup returns the output file name for blockType when
+// --group-by-type is enabled. Entries in overrides (the file-groups setting)
+// take precedence over fileGroups; the "default" entry replaces the
+// defaultFileGroup fallback for types that are not mapped anywhere.
+func getFileGroup(overrides map[string]string, blockType string) string {
+	if v, ok := overrides[blockType]; ok {
+		return v
+	}
+	if v, ok := fileGroups[blockType]; ok {
+		return v
+	}
+	if v, ok := overrides[defaultFileGroupKey]; ok {
+		return v
+	}
+	return defaultFileGroup
+}
+
+// validateFileGroups checks that every file-groups entry names a plain .tf
+// file inside the output directory.
+func validateFileGroups(overrides map[string]string) error {
+	for blockType, fileName := range overrides {
+		if fileName == "" || filepath.Base(fileName) != fileName {
+			return fmt.Errorf("file-groups[%s]: %q must be a file name without a directory", blockType, fileName)
+		}
+		if filepath.Ext(fileName) != ".tf" {
+			return fmt.Errorf("file-groups[%s]: %q must have a .tf extension", blockType, fileName)
+		}
+	}
+	return nil
+}
+
+// blockTypeOrder ranks top-level block types for BlockListSorter.
+// Types listed in custom sort first, in list order; all other types keep
+// their built-in relative order after them. The zero value reproduces the
+// built-in blockTypePriority ordering.
+type blockTypeOrder struct {
+	custom map[string]int
+}
+
+// newBlockTypeOrder builds a blockTypeOrder from the block-order setting.
+func newBlockTypeOrder(order []string) blockTypeOrder {
+	if len(order) == 0 {
+		return blockTypeOrder{}
+	}
+	custom := make(map[string]int, len(order))
+	for i, blockType := range order {
+		if _, ok := custom[blockType]; !ok {
+			custom[blockType] = i
+		}
+	}
+	return blockTypeOrder{custom: custom}
+}
+
+// priority returns the sort priority of blockType. Lower values sort first.
+func (o blockTypeOrder) priority(blockType string) int {
+	if p, ok := o.custom[blockType]; ok {
+		return p
+	}
+	return len(o.custom) + getBlockTypePriority(blockType)
+}
+
+// blockComparator describes how blocks of the same type are ordered
+// relative to each other.
+type blockComparator struct {
+	// preserve keeps blocks in their original (declaration) order.
+	preserve bool
+	// labelIndex is the label compared first; the full label list breaks ties.
+	labelIndex int
+}
+
+// parseBlockComparator parses a block-comparators value such as
+// "alphabetical", "preserve-original" or "label:1".
+func parseBlockComparator(value string) (blockComparator, error) {
+	switch {
+	case value == comparatorAlphabetical:
+		return blockComparator{}, nil
+	case value == comparatorPreserveOriginal:
+		return blockComparator{preserve: true}, nil
+	case strings.HasPrefix(value, comparatorLabelPrefix):
+		idx, err := strconv.Atoi(strings.TrimPrefix(value, comparatorLabelPrefix))
+		if err != nil || idx < 0 {
+			return blockComparator{}, fmt.Errorf("invalid label index in comparator %q", value)
+		}
+		return blockComparator{labelIndex: idx}, nil
+	}
+	return blockComparator{}, fmt.Errorf("unknown comparator %q (expected %s, %s or %s<n>)",
+		value, comparatorAlphabetical, comparatorPreserveOriginal, comparatorLabelPrefix)
+}
+
+// parseBlockComparators parses the block-comparators setting. Invalid
+// entries are skipped; validateBlockOrdering reports them to the user.
+func parseBlockComparators(values map[string]string) map[string]blockComparator {
+	if len(values) == 0 {
+		return nil
+	}
+	comparators := make(map[string]blockComparator, len(values))
+	for blockType, value := range values {
+		if c, err := parseBlockComparator(value); err == nil {
+			comparators[blockType] = c
+		}
+	}
+	return comparators
+}
+
+// validateBlockOrdering checks the block-order and block-comparators settings.
+func validateBlockOrdering(order []string, comparators map[string]string) error {
+	seen := make(map[string]bool, len(order))
+	for _, blockType := range order {
+		if blockType == "" {
+			return fmt.Errorf("block-order contains an empty block type")
+		}
+		if seen[blockType] {
+			return fmt.Errorf("block-order lists %q more than once", blockType)
+		}
+		seen[blockType] = true
+	}
+	for blockType, value := range comparators {
+		if _, err := parseBlockComparator(value); err != nil {
+			return fmt.Errorf("block-comparators[%s]: %w", blockType, err)
+		}
+	}
+	return nil
+}
+
+// MetaArgumentList overrides the arguments and nested blocks placed first
+// (Pre) and last (Post) inside a block type. A nil list keeps the built-in
+// value; an explicit empty list clears it.
+type MetaArgumentList struct {
+	Pre  []string `yaml:"pre"`
+	Post []string `yaml:"post"`
+}
+
+// knownBlockTypes lists the Terraform language block types that may appear in
+// the meta-arguments setting in addition to those in metaArguments. Other
+// names are accepted (provider schemas define their own nested blocks) but
+// reported as a warning since they are most likely typos.
+var knownBlockTypes = []string{
+	"assert", "backend", "cloud", "connection", "content", "lifecycle",
+	"locals", "postcondition", "precondition", "provider", "provider_meta",
+	"provisioner", "required_providers", "validation",
+}
+
+// mergeMetaArguments returns a copy of metaArguments with the meta-arguments
+// setting applied on top. It returns metaArguments itself when there is
+// nothing to merge.
+func mergeMetaArguments(overrides map[string]MetaArgumentList) map[string]map[string][]string {
+	if len(overrides) == 0 {
+		return metaArguments
+	}
+
+	// Empty built-in lists become nil, so that a default entry in the
+	// setting still applies to them; only the setting can clear a list.
+	merged := make(map[string]map[string][]string, len(metaArguments)+len(overrides))
+	for blockType, args := range metaArguments {
+		merged[blockType] = map[string][]string{
+			"pre":  nilIfEmpty(args["pre"]),
+			"post": nilIfEmpty(args["post"]),
+		}
+	}
+	for blockType, override := range overrides {
+		args := map[string][]string{
+			"pre":  merged[blockType]["pre"],
+			"post": merged[blockType]["post"],
+		}
+		if override.Pre != nil {
+			args["pre"] = override.Pre
+		}
+		if override.Post != nil {
+			args["post"] = override.Post
+		}
+		merged[blockType] = args
+	}
+	return merged
+}
+
+// nilIfEmpty returns nil for an empty list and list otherwise.
+func nilIfEmpty(list []string) []string {
+	if len(list) == 0 {
+		return nil
+	}
+	return list
+}
+
+// validateMetaArguments checks the meta-arguments setting. Malformed lists are
+// returned as an error; unknown block types are logged as a warning.
+func validateMetaArguments(overrides map[string]MetaArgumentList) error {
+	for blockType, override := range overrides {
+		if !hclsyntax.ValidIdentifier(blockType) {
+			return fmt.Errorf("meta-arguments: %q is not a valid block type", blockType)
+		}
+		if _, ok := metaArguments[blockType]; !ok && !stringExists(knownBlockTypes, blockType) {
+			log.WithField("blockType", blockType).Warnln("meta-arguments: unknown block type; check for a typo")
+		}
+
+		seen := make(map[string]string)
+		for _, list := range []struct {
+			name string
+			args []string
+		}{{"pre", override.Pre}, {"post", override.Post}} {
+			for _, arg := range list.args {
+				if !hclsyntax.ValidIdentifier(arg) {
+					return fmt.Errorf("meta-arguments[%s].%s: %q is not a valid argument name", blockType, list.name, arg)
+				}
+				if prev, ok := seen[arg]; ok {
+					return fmt.Errorf("meta-arguments[%s]: %q is listed more than once (in %s and %s)", blockType, arg, prev, list.name)
+				}
+				seen[arg] = list.name
+			}
+		}
+	}
+	return nil
+}
+
+// getMetaArguments returns the built-in meta arguments that should be first
+// and last inside of a block.
 func getMetaArguments(block *hclsyntax.Block) [][]string {
-	log.WithFields(log.Fields{blockTypeLabel: block.Type, blockLabelsLabel: block.Labels}).Traceln("Starting getMetaArguments")
+	return lookupMetaArguments(metaArguments, block)
+}
+
+// lookupMetaArguments returns the meta arguments that should be first and last
+// inside of a block, using the given block type table.
+func lookupMetaArguments(table map[string]map[string][]string, block *hclsyntax.Block) [][]string {
+	log.WithFields(log.Fields{blockTypeLabel: block.Type, blockLabelsLabel: block.Labels}).Traceln("Starting lookupMetaArguments")
 
 	// Initialize the return value
 	metaArgs := make([][]string, 2)
 
 	// Check if the block type has meta arguments
-	if blockType, ok := metaArguments[block.Type]; ok {
+	if blockType, ok := table[block.Type]; ok {
 		if args, ok := blockType["pre"]; ok {
 			metaArgs[0] = args
 		}
@@ -155,12 +429,13 @@ func getMetaArguments(block *hclsyntax.Block) [][]string {
 		}
 	}
 
-	// If the block type doesn't have meta arguments, use the default ones
-	if len(metaArgs[0]) == 0 {
-		metaArgs[0] = metaArguments["default"]["pre"]
+	// If the block type doesn't have meta arguments, use the default ones.
+	// An explicit empty list is kept.
+	if metaArgs[0] == nil {
+		metaArgs[0] = table["default"]["pre"]
 	}
-	if len(metaArgs[1]) == 0 {
-		metaArgs[1] = metaArguments["default"]["post"]
+	if metaArgs[1] == nil {
+		metaArgs[1] = table["default"]["post"]
 	}
 
 	return metaArgs
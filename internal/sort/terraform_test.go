@@ -2,6 +2,7 @@ package sort
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	hclsyntax "github.com/hashicorp/hcl/v2/hclsyntax"
@@ -76,8 +77,8 @@ func TestGetMetaArguments(t *testing.T) {
 		{
 			name:      "dynamic block",
 			blockType: "dynamic",
-			wantPre:   []string{"for_each"},
-			wantPost:  []string{},
+			wantPre:   []string{"for_each", "iterator", "labels"},
+			wantPost:  []string{"content"},
 		},
 		{
 			name:      "local block",
@@ -152,3 +153,211 @@ func TestGetMetaArguments(t *testing.T) {
 		})
 	}
 }
+
+func TestBlockTypeOrderPriority(t *testing.T) {
+	t.Run("zero value matches built-in priority", func(t *testing.T) {
+		var o blockTypeOrder
+		for blockType, want := range blockTypePriority {
+			if got := o.priority(blockType); got != want {
+				t.Errorf("priority(%q) = %d, want %d", blockType, got, want)
+			}
+		}
+	})
+
+	t.Run("listed types come first in list order", func(t *testing.T) {
+		o := newBlockTypeOrder([]string{"module", "resource"})
+		ordered := []string{"module", "resource", "terraform", "variable", "data", "unknown_type", "import", "output"}
+		for i := 0; i < len(ordered)-1; i++ {
+			if o.priority(ordered[i]) >= o.priority(ordered[i+1]) {
+				t.Errorf("%s (%d) should come before %s (%d)",
+					ordered[i], o.priority(ordered[i]), ordered[i+1], o.priority(ordered[i+1]))
+			}
+		}
+	})
+}
+
+func TestParseBlockComparator(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    blockComparator
+		wantErr bool
+	}{
+		{value: "alphabetical", want: blockComparator{}},
+		{value: "preserve-original", want: blockComparator{preserve: true}},
+		{value: "label:1", want: blockComparator{labelIndex: 1}},
+		{value: "label:-1", wantErr: true},
+		{value: "label:x", wantErr: true},
+		{value: "random", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseBlockComparator(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseBlockComparator(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseBlockComparator(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateBlockOrdering(t *testing.T) {
+	tests := []struct {
+		name        string
+		order       []string
+		comparators map[string]string
+		wantErr     string
+	}{
+		{name: "empty config", wantErr: ""},
+		{name: "valid config", order: []string{"module", "resource"}, comparators: map[string]string{"output": "preserve-original"}},
+		{name: "duplicate type", order: []string{"module", "module"}, wantErr: `block-order lists "module" more than once`},
+		{name: "empty type", order: []string{""}, wantErr: "empty block type"},
+		{name: "bad comparator", comparators: map[string]string{"output": "reverse"}, wantErr: "block-comparators[output]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBlockOrdering(tt.order, tt.comparators)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGetFileGroup(t *testing.T) {
+	overrides := map[string]string{
+		"provider":  "providers.tf",
+		"terraform": "terraform.tf",
+		"default":   "resources.tf",
+	}
+
+	tests := []struct {
+		name      string
+		overrides map[string]string
+		blockType string
+		want      string
+	}{
+		{name: "built-in mapping", blockType: "variable", want: "variables.tf"},
+		{name: "built-in fallback", blockType: "resource", want: defaultFileGroup},
+		{name: "override replaces built-in", overrides: overrides, blockType: "terraform", want: "terraform.tf"},
+		{name: "override extends mapping", overrides: overrides, blockType: "provider", want: "providers.tf"},
+		{name: "built-in kept when not overridden", overrides: overrides, blockType: "output", want: "outputs.tf"},
+		{name: "default key replaces fallback", overrides: overrides, blockType: "resource", want: "resources.tf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getFileGroup(tt.overrides, tt.blockType); got != tt.want {
+				t.Errorf("getFileGroup(%q) = %q, want %q", tt.blockType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateFileGroups(t *testing.T) {
+	valid := map[string]string{"provider": "providers.tf", "default": "main.tf"}
+	if err := validateFileGroups(valid); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, fileName := range []string{"", "nested/providers.tf", "providers.hcl"} {
+		if err := validateFileGroups(map[string]string{"provider": fileName}); err == nil {
+			t.Errorf("expected error for file name %q, got nil", fileName)
+		}
+	}
+}
+
+func TestMergeMetaArguments(t *testing.T) {
+	t.Run("no overrides returns built-in table", func(t *testing.T) {
+		got := mergeMetaArguments(nil)
+		if !reflect.DeepEqual(got, metaArguments) {
+			t.Error("expected the built-in table when there are no overrides")
+		}
+	})
+
+	merged := mergeMetaArguments(map[string]MetaArgumentList{
+		"resource":  {Post: []string{"provisioner", "lifecycle", "depends_on", "tags"}},
+		"module":    {Pre: []string{"source", "version", "providers"}},
+		"lifecycle": {Pre: []string{"create_before_destroy"}, Post: []string{}},
+	})
+
+	tests := []struct {
+		blockType string
+		wantPre   []string
+		wantPost  []string
+	}{
+		{"resource", []string{"count", "for_each", "provider"}, []string{"provisioner", "lifecycle", "depends_on", "tags"}},
+		{"module", []string{"source", "version", "providers"}, []string{"depends_on"}},
+		{"lifecycle", []string{"create_before_destroy"}, []string{}},
+		{"variable", []string{"description", "type", "default", "nullable", "sensitive"}, []string{"validation"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.blockType, func(t *testing.T) {
+			got := lookupMetaArguments(merged, &hclsyntax.Block{Type: tt.blockType})
+			if !reflect.DeepEqual(got[0], tt.wantPre) {
+				t.Errorf("pre = %v, want %v", got[0], tt.wantPre)
+			}
+			if !reflect.DeepEqual(got[1], tt.wantPost) {
+				t.Errorf("post = %v, want %v", got[1], tt.wantPost)
+			}
+		})
+	}
+
+	if len(metaArguments["module"]["pre"]) != 5 {
+		t.Error("mergeMetaArguments must not modify the built-in table")
+	}
+
+	t.Run("empty list clears despite a default override", func(t *testing.T) {
+		merged := mergeMetaArguments(map[string]MetaArgumentList{
+			"default":  {Post: []string{"tags"}},
+			"resource": {Post: []string{}},
+		})
+		if got := lookupMetaArguments(merged, &hclsyntax.Block{Type: "resource"}); !reflect.DeepEqual(got[1], []string{}) {
+			t.Errorf("resource post = %v, want []", got[1])
+		}
+		for _, blockType := range []string{"check", "unknown_block_type"} {
+			if got := lookupMetaArguments(merged, &hclsyntax.Block{Type: blockType}); !reflect.DeepEqual(got[1], []string{"tags"}) {
+				t.Errorf("%s post = %v, want the default override [tags]", blockType, got[1])
+			}
+		}
+	})
+}
+
+func TestValidateMetaArguments(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides map[string]MetaArgumentList
+		wantErr   string
+	}{
+		{name: "valid", overrides: map[string]MetaArgumentList{"resource": {Post: []string{"tags"}}}},
+		{name: "unknown type only warns", overrides: map[string]MetaArgumentList{"ingress": {Pre: []string{"from_port"}}}},
+		{name: "invalid block type", overrides: map[string]MetaArgumentList{"bad type": {}}, wantErr: "not a valid block type"},
+		{name: "invalid argument", overrides: map[string]MetaArgumentList{"resource": {Pre: []string{"for-each!"}}}, wantErr: "resource].pre"},
+		{name: "duplicate argument", overrides: map[string]MetaArgumentList{"resource": {Pre: []string{"tags"}, Post: []string{"tags"}}}, wantErr: "more than once"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateMetaArguments(tt.overrides)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
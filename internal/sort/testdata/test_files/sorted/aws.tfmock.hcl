@@ -0,0 +1,21 @@
+mock_resource "aws_instance" {
+  defaults = {
+    id = "i-123"
+  }
+}
+
+mock_resource "aws_s3_bucket" {
+  defaults = {
+    arn = "arn:aws:s3:::bucket"
+  }
+}
+
+mock_data "aws_region" {
+  defaults = {
+    name = "us-east-1"
+  }
+}
+
+override_resource {
+  target = aws_instance.web
+}
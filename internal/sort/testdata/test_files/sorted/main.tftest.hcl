@@ -0,0 +1,40 @@
+variables {
+  region = "us-east-1"
+}
+
+provider "aws" {
+  region = var.region
+}
+
+mock_provider "aws" {
+  alias = "mock"
+}
+
+run "second" {
+  command = plan
+
+  variables {
+    count = 2
+    name  = "b"
+  }
+
+  assert {
+    condition     = output.b == 2
+    error_message = "b is wrong"
+  }
+
+  assert {
+    condition     = output.a == 1
+    error_message = "a is wrong"
+  }
+
+  expect_failures = [var.name]
+}
+
+# Runs the setup module first.
+run "first" {
+  module {
+    source  = "./setup"
+    version = "1.0.0"
+  }
+}
@@ -0,0 +1,21 @@
+override_resource {
+  target = aws_instance.web
+}
+
+mock_data "aws_region" {
+  defaults = {
+    name = "us-east-1"
+  }
+}
+
+mock_resource "aws_s3_bucket" {
+  defaults = {
+    arn = "arn:aws:s3:::bucket"
+  }
+}
+
+mock_resource "aws_instance" {
+  defaults = {
+    id = "i-123"
+  }
+}
@@ -0,0 +1,36 @@
+run "second" {
+  assert {
+    condition     = output.b == 2
+    error_message = "b is wrong"
+  }
+  expect_failures = [var.name]
+  command         = plan
+  variables {
+    name = "b"
+    count = 2
+  }
+  assert {
+    condition     = output.a == 1
+    error_message = "a is wrong"
+  }
+}
+
+# Runs the setup module first.
+run "first" {
+  module {
+    version = "1.0.0"
+    source  = "./setup"
+  }
+}
+
+mock_provider "aws" {
+  alias = "mock"
+}
+
+variables {
+  region = "us-east-1"
+}
+
+provider "aws" {
+  region = var.region
+}
@@ -0,0 +1,70 @@
+package sort
+
+import (
+	"maps"
+	"strings"
+)
+
+// Suffixes of Terraform test files and mock data files.
+const (
+	testFileSuffix = ".tftest.hcl"
+	mockFileSuffix = ".tfmock.hcl"
+)
+
+// testFileBlockOrder is the top-level block order of test and mock data
+// files: the configuration shared by every run first, then the run blocks.
+var testFileBlockOrder = []string{
+	"variables",
+	"provider",
+	"mock_provider",
+	"mock_resource",
+	"mock_data",
+	"override_resource",
+	"override_data",
+	"override_module",
+	"run",
+}
+
+// runMetaArguments is the canonical argument order inside run blocks. The
+// post list keeps its order: variables, then the assertions, then the
+// expected failures.
+var runMetaArguments = MetaArgumentList{
+	Pre:  []string{"command", "module"},
+	Post: []string{"variables", "assert", "expect_failures"},
+}
+
+// isTestFile reports whether path is a Terraform test file (.tftest.hcl) or
+// mock data file (.tfmock.hcl).
+func isTestFile(path string) bool {
+	return strings.HasSuffix(path, testFileSuffix) || strings.HasSuffix(path, mockFileSuffix)
+}
+
+// testFileSorter returns a sorter for test and mock data files. run blocks
+// execute in file order, so they keep their original order and follow the
+// other blocks; block-order, order-by-dependencies and group-by-type do not
+// apply. A meta-arguments entry for run replaces the canonical order.
+func (s *Sorter) testFileSorter() *Sorter {
+	params := *s.params
+	params.BlockOrder = testFileBlockOrder
+	params.NoSortByType = false
+	params.OrderByDependencies = false
+	params.GroupByType = false
+
+	params.BlockComparators = maps.Clone(s.params.BlockComparators)
+	if params.BlockComparators == nil {
+		params.BlockComparators = map[string]string{}
+	}
+	params.BlockComparators["run"] = comparatorPreserveOriginal
+
+	params.MetaArguments = maps.Clone(s.params.MetaArguments)
+	if params.MetaArguments == nil {
+		params.MetaArguments = map[string]MetaArgumentList{}
+	}
+	if _, ok := params.MetaArguments["run"]; !ok {
+		params.MetaArguments["run"] = runMetaArguments
+	}
+
+	sorter := NewSorter(&params, s.fs)
+	sorter.testProfile = true
+	return sorter
+}
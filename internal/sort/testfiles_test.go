@@ -0,0 +1,210 @@
+package sort
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+// unsortedTestFile is a test file whose run blocks are out of alphabetical
+// order.
+const unsortedTestFile = `run "b" {
+  variables {
+    name = "b"
+  }
+  command = plan
+}
+
+run "a" {
+  command = apply
+}
+
+variables {
+  name = "x"
+}
+`
+
+func TestTestFileSorter(t *testing.T) {
+	tests := []struct {
+		name   string
+		params *Params
+		want   string
+	}{
+		{
+			name:   "run blocks keep their order",
+			params: &Params{BlockOrder: []string{"run"}, OrderByDependencies: true, NoSortByType: true},
+			want: `variables {
+  name = "x"
+}
+
+run "b" {
+  command = plan
+
+  variables {
+    name = "b"
+  }
+}
+
+run "a" {
+  command = apply
+}
+`,
+		},
+		{
+			name:   "meta-arguments override the run order",
+			params: &Params{MetaArguments: map[string]MetaArgumentList{"run": {Pre: []string{"variables"}}}},
+			want: `variables {
+  name = "x"
+}
+
+run "b" {
+  variables {
+    name = "b"
+  }
+
+  command = plan
+}
+
+run "a" {
+  command = apply
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memFS := afero.NewMemMapFs()
+			_ = memFS.MkdirAll("/mod", 0755)
+			_ = afero.WriteFile(memFS, "/mod/variables.tf", []byte("variable \"name\" {}\n"), 0644)
+			_ = afero.WriteFile(memFS, "/mod/main.tftest.hcl", []byte(unsortedTestFile), 0644)
+			s := NewSorter(tt.params, memFS)
+			got, err := s.sortFile("/mod/main.tftest.hcl")
+			if err != nil {
+				t.Fatalf("sortFile() error = %v", err)
+			}
+			if string(got["main.tftest.hcl"]) != tt.want {
+				t.Errorf("sortFile() =\n%s\nwant:\n%s", got["main.tftest.hcl"], tt.want)
+			}
+		})
+	}
+}
+
+func TestTestFilesGroupByType(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/mod", 0755)
+	_ = afero.WriteFile(memFS, "/mod/variables.tf", []byte("variable \"name\" {}\n"), 0644)
+	_ = afero.WriteFile(memFS, "/mod/main.tftest.hcl", []byte(unsortedTestFile), 0644)
+	s := NewSorter(&Params{GroupByType: true, OutputDir: "/out"}, memFS)
+	if err := s.run("/mod"); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	got, err := afero.ReadFile(memFS, "/out/main.tftest.hcl")
+	if err != nil {
+		t.Fatalf("expected main.tftest.hcl in the output directory: %v", err)
+	}
+	if !strings.HasPrefix(string(got), "variables {") {
+		t.Errorf("main.tftest.hcl =\n%s\nwant the variables block first", got)
+	}
+	variables, _ := afero.ReadFile(memFS, "/out/variables.tf")
+	if strings.Contains(string(variables), "run") {
+		t.Errorf("variables.tf should not receive test blocks, got:\n%s", variables)
+	}
+}
+
+// TestTestFilesWithoutBlocksInPlace verifies that an in-place
+// group-by-type run keeps test and mock data files without blocks as
+// written.
+func TestTestFilesWithoutBlocksInPlace(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/mod", 0755)
+	_ = afero.WriteFile(memFS, "/mod/main.tf", []byte("variable \"name\" {}\n"), 0644)
+	_ = afero.WriteFile(memFS, "/mod/todo.tftest.hcl", []byte("# Tests go here.\n"), 0644)
+	_ = afero.WriteFile(memFS, "/mod/empty.tfmock.hcl", []byte(""), 0644)
+	s := NewSorter(&Params{GroupByType: true, Inline: true}, memFS)
+	sorted, err := s.sortFiles([]string{"/mod/main.tf", "/mod/todo.tftest.hcl", "/mod/empty.tfmock.hcl"})
+	if err != nil {
+		t.Fatalf("sortFiles() error = %v", err)
+	}
+	for _, name := range []string{"todo.tftest.hcl", "empty.tfmock.hcl"} {
+		if _, ok := sorted[name]; !ok {
+			t.Errorf("sortFiles() dropped %s", name)
+		}
+	}
+
+	if err := s.run("/mod"); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	if got, err := afero.ReadFile(memFS, "/mod/todo.tftest.hcl"); err != nil || string(got) != "# Tests go here.\n" {
+		t.Errorf("todo.tftest.hcl = %q, %v; want it unchanged", got, err)
+	}
+	if exists, _ := afero.Exists(memFS, "/mod/empty.tfmock.hcl"); !exists {
+		t.Error("expected empty.tfmock.hcl to be kept")
+	}
+	if exists, _ := afero.Exists(memFS, "/mod/variables.tf"); !exists {
+		t.Error("expected variables.tf to be written")
+	}
+}
+
+func TestLintTestFile(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/mod", 0755)
+	_ = afero.WriteFile(memFS, "/mod/main.tftest.hcl", []byte(unsortedTestFile), 0644)
+
+	violations, err := NewSorter(&Params{}, memFS).lintFiles([]string{"/mod/main.tftest.hcl"})
+	if err != nil {
+		t.Fatalf("lintFiles() error = %v", err)
+	}
+	var got []string
+	for _, v := range violations {
+		got = append(got, v.String())
+	}
+	want := []string{
+		"/mod/main.tftest.hcl:5:3: `command` must be the first argument in run b",
+		"/mod/main.tftest.hcl:12:1: variables should come before run \"b\"",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("violations =\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSummarizeTestFile(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = memFS.MkdirAll("/mod", 0755)
+	_ = afero.WriteFile(memFS, "/mod/main.tftest.hcl", []byte(unsortedTestFile), 0644)
+
+	s := NewSorter(&Params{}, memFS)
+	files := []string{"/mod/main.tftest.hcl"}
+	sortedFiles, err := s.sortFiles(files)
+	if err != nil {
+		t.Fatalf("sortFiles() error = %v", err)
+	}
+	summary, err := s.summarize("/mod", files, sortedFiles)
+	if err != nil {
+		t.Fatalf("summarize() error = %v", err)
+	}
+	if want := "run.b: arguments reordered (command, variables)"; !slices.Contains(summary.bodies, want) {
+		t.Errorf("bodies = %v, want %q", summary.bodies, want)
+	}
+	if len(summary.moves) == 0 {
+		t.Error("expected the variables block to be reported as moved")
+	}
+}
+
+func TestIsTestFile(t *testing.T) {
+	tests := map[string]bool{
+		"main.tftest.hcl":      true,
+		"tests/aws.tfmock.hcl": true,
+		"main.tf":              false,
+		"main.hcl":             false,
+	}
+	for path, want := range tests {
+		if got := isTestFile(path); got != want {
+			t.Errorf("isTestFile(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
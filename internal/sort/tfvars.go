@@ -0,0 +1,235 @@
+package sort
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	hclsyntax "github.com/hashicorp/hcl/v2/hclsyntax"
+	log "github.com/sirupsen/logrus"
+)
+
+// Orders accepted by the vars-order setting.
+const (
+	varsOrderAlphabetical = "alphabetical"
+	varsOrderDeclaration  = "declaration"
+)
+
+// isVarsFile reports whether path is a variable definitions file:
+// .tfvars, .auto.tfvars or their .json forms.
+func isVarsFile(path string) bool {
+	return strings.HasSuffix(path, ".tfvars") || strings.HasSuffix(path, ".tfvars.json")
+}
+
+// sortsAlone reports whether the file at path is sorted on its own and keeps
+// its name, even with group-by-type: JSON, variable definitions, test and
+// mock data files.
+func sortsAlone(path string) bool {
+	return isJSONFile(path) || isVarsFile(path) || isTestFile(path)
+}
+
+// validateVarsOrder checks the vars-order setting.
+func validateVarsOrder(order string) error {
+	switch order {
+	case "", varsOrderAlphabetical, varsOrderDeclaration:
+		return nil
+	}
+	return fmt.Errorf("unknown vars-order %q (expected %s or %s)", order, varsOrderAlphabetical, varsOrderDeclaration)
+}
+
+// variableLess returns the comparison for the assignments of the variable
+// definitions file filename. With the declaration order, variables declared
+// in the directory of the file come first, in the order of their variable
+// blocks; the others follow with the configured collation.
+func (s *Sorter) variableLess(filename string) (func(a, b string) bool, error) {
+	if s.params.VarsOrder != varsOrderDeclaration {
+		return s.collation.less, nil
+	}
+
+	declared, err := s.declaredVariables(filepath.Dir(filename))
+	if err != nil {
+		return nil, fmt.Errorf("could not read variable declarations: %w", err)
+	}
+	rank := map[string]int{}
+	for i, name := range declared {
+		if _, ok := rank[name]; !ok {
+			rank[name] = i
+		}
+	}
+
+	return func(a, b string) bool {
+		rankA, okA := rank[a]
+		rankB, okB := rank[b]
+		switch {
+		case okA && okB:
+			return rankA < rankB
+		case okA != okB:
+			return okA
+		}
+		return s.collation.less(a, b)
+	}, nil
+}
+
+// declaredVariables returns the names of the variable blocks of the .tf and
+// .tf.json files in dir, by file name and then position. The files are the
+// input files of the run in dir, so excluded files are skipped; a run of a
+// single file reads the files of dir that are not excluded.
+func (s *Sorter) declaredVariables(dir string) ([]string, error) {
+	log.WithField("dir", dir).Traceln("Starting declaredVariables")
+
+	var files []string
+	for _, f := range s.inputFiles {
+		if filepath.Dir(f) == filepath.Clean(dir) && declaresVariables(f) {
+			files = append(files, f)
+		}
+	}
+	if len(files) == 0 {
+		var err error
+		if files, err = s.getFilesInFolder(dir); err != nil {
+			return nil, err
+		}
+	}
+	files = slices.Clone(files)
+	sort.Strings(files)
+
+	var names []string
+	for _, path := range files {
+		switch {
+		case isJSONFile(path):
+			content, err := s.afs.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			root, err := parseJSON(content, path)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			for _, m := range root.members {
+				if m.key != "variable" {
+					continue
+				}
+				for _, v := range m.value.members {
+					names = append(names, v.key)
+				}
+			}
+		case filepath.Ext(path) == ".tf":
+			body, err := s.parseHclFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			for _, block := range body.Blocks {
+				if block.Type == "variable" && len(block.Labels) == 1 {
+					names = append(names, block.Labels[0])
+				}
+			}
+		}
+	}
+	return names, nil
+}
+
+// declaresVariables reports whether the file at path can declare variables:
+// a .tf or .tf.json file.
+func declaresVariables(path string) bool {
+	return isJSONFile(path) || filepath.Ext(path) == ".tf"
+}
+
+// unchangedFile returns the content of filename as written, comments
+// included. Files that are sorted on their own but have nothing to sort,
+// such as a comment-only variable definitions or test file, keep their output file
+// instead of being treated as emptied.
+func (s *Sorter) unchangedFile(filename string) (map[string][]byte, error) {
+	lines, err := s.getLinesFromFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("could not read file: %w", err)
+	}
+	var content []byte
+	if len(lines) > 0 {
+		content = []byte(strings.Join(lines, "\n") + "\n")
+	}
+	return map[string][]byte{getFileNameFromPath(filename): content}, nil
+}
+
+// sortVariableAssignments sorts the assignments of a variable definitions
+// file. Each assignment keeps its leading comment and its value as written;
+// multi-line assignments are separated from their neighbours by an empty
+// line.
+func (s *Sorter) sortVariableAssignments(body *hclsyntax.Body, filename string) (map[string][]byte, error) {
+	log.WithField("filename", filename).Traceln("Starting sortVariableAssignments")
+
+	if len(body.Blocks) > 0 {
+		block := body.Blocks[0]
+		return nil, fmt.Errorf("%s:%d: variable definitions files can only contain assignments, found a %s block",
+			filename, block.TypeRange.Start.Line, block.Type)
+	}
+
+	attributes := make([]*hclsyntax.Attribute, 0, len(body.Attributes))
+	for _, attribute := range body.Attributes {
+		attributes = append(attributes, attribute)
+	}
+	sort.Slice(attributes, func(i, j int) bool {
+		return attributes[i].SrcRange.Start.Byte < attributes[j].SrcRange.Start.Byte
+	})
+
+	// The bodies scope keeps the assignment order untouched.
+	if s.params.Scope != scopeBodies {
+		less, err := s.variableLess(filename)
+		if err != nil {
+			return nil, err
+		}
+		sort.SliceStable(attributes, func(i, j int) bool {
+			return less(attributes[i].Name, attributes[j].Name)
+		})
+	}
+
+	path, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, fmt.Errorf("could not get absolute path: %w", err)
+	}
+
+	var output []byte
+	previousMultiline := false
+	for i, attribute := range attributes {
+		b, err := s.getAttributeBytes(attribute, path)
+		if err != nil {
+			return nil, fmt.Errorf("could not write assignment: %w", err)
+		}
+		multiline := bytes.Count(b, []byte("\n")) > 1
+		if i > 0 && (multiline || previousMultiline) {
+			output = append(output, '\n')
+		}
+		output = append(output, b...)
+		previousMultiline = multiline
+	}
+
+	output = bytes.TrimLeft(output, "\n")
+	return map[string][]byte{getFileNameFromPath(filename): output}, nil
+}
+
+// sortVariablesJSON sorts the properties of a .tfvars.json file. Values are
+// copied as written.
+func (s *Sorter) sortVariablesJSON(content []byte, filename string) ([]byte, error) {
+	log.WithField("filename", filename).Traceln("Starting sortVariablesJSON")
+
+	root, err := parseJSON(content, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.params.Scope != scopeBodies {
+		less, err := s.variableLess(filename)
+		if err != nil {
+			return nil, err
+		}
+		sort.SliceStable(root.members, func(i, j int) bool {
+			return less(root.members[i].key, root.members[j].key)
+		})
+	}
+
+	var buf bytes.Buffer
+	writeJSONValue(&buf, root, "")
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,213 @@
+package sort
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+// unsortedVars is a variable definitions file with commented, multi-line
+// and inline-commented assignments out of order.
+const unsortedVars = `# Region to deploy.
+region = "us-east-1"
+tags = {
+  z = "1"
+  a = "2"
+}
+
+# Instance count.
+instance_count = 2
+ami = "abc" # inline
+`
+
+// unsortedVarsJSON is a .tfvars.json file with its variables out of order.
+const unsortedVarsJSON = `{"zeta": 1, "ami": {"b": 1, "a": 2}}`
+
+func TestSortVariableAssignments(t *testing.T) {
+	tests := []struct {
+		name   string
+		params *Params
+		file   string
+		want   string
+	}{
+		{
+			name:   "alphabetical",
+			params: &Params{},
+			file:   "/vars/terraform.tfvars",
+			want: `ami = "abc" # inline
+
+# Instance count.
+instance_count = 2
+
+# Region to deploy.
+region = "us-east-1"
+
+tags = {
+  z = "1"
+  a = "2"
+}
+`,
+		},
+		{
+			name:   "declaration order",
+			params: &Params{VarsOrder: varsOrderDeclaration},
+			file:   "/vars/terraform.tfvars",
+			want: `# Region to deploy.
+region = "us-east-1"
+
+ami = "abc" # inline
+
+# Instance count.
+instance_count = 2
+
+tags = {
+  z = "1"
+  a = "2"
+}
+`,
+		},
+		{
+			name:   "remove comments",
+			params: &Params{RemoveComments: true},
+			file:   "/vars/terraform.tfvars",
+			want: `ami            = "abc"
+instance_count = 2
+region         = "us-east-1"
+
+tags = {
+  z = "1"
+  a = "2"
+}
+`,
+		},
+		{
+			name:   "json",
+			params: &Params{},
+			file:   "/vars/extra.auto.tfvars.json",
+			want: `{
+  "ami": {
+    "b": 1,
+    "a": 2
+  },
+  "zeta": 1
+}
+`,
+		},
+		{
+			name:   "comment only",
+			params: &Params{},
+			file:   "/vars/empty.auto.tfvars",
+			want:   "# Nothing here yet.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memFS := afero.NewMemMapFs()
+			_ = memFS.MkdirAll("/vars", 0755)
+			_ = afero.WriteFile(memFS, "/vars/variables.tf", []byte("variable \"region\" {}\n\nvariable \"ami\" {}\n"), 0644)
+			_ = afero.WriteFile(memFS, "/vars/terraform.tfvars", []byte(unsortedVars), 0644)
+			_ = afero.WriteFile(memFS, "/vars/extra.auto.tfvars.json", []byte(unsortedVarsJSON), 0644)
+			_ = afero.WriteFile(memFS, "/vars/empty.auto.tfvars", []byte("# Nothing here yet.\n"), 0644)
+			s := NewSorter(tt.params, memFS)
+			got, err := s.sortFile(tt.file)
+			if err != nil {
+				t.Fatalf("sortFile() error = %v", err)
+			}
+			name := tt.file[strings.LastIndex(tt.file, "/")+1:]
+			if content, ok := got[name]; !ok || string(content) != tt.want {
+				t.Errorf("sortFile() =\n%s\nwant:\n%s", content, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortVariableAssignmentsRejectsBlocks(t *testing.T) {
+	memFS := afero.NewMemMapFs()
+	_ = afero.WriteFile(memFS, "/bad.tfvars", []byte("a = 1\n\nlocals {\n  b = 2\n}\n"), 0644)
+
+	s := NewSorter(&Params{}, memFS)
+	_, err := s.sortFile("/bad.tfvars")
+	if err == nil || !strings.Contains(err.Error(), "can only contain assignments") {
+		t.Errorf("sortFile() error = %v, want an assignments-only error", err)
+	}
+}
+
+func TestValidateVarsOrder(t *testing.T) {
+	for _, order := range []string{"", varsOrderAlphabetical, varsOrderDeclaration} {
+		if err := validateVarsOrder(order); err != nil {
+			t.Errorf("validateVarsOrder(%q) error = %v", order, err)
+		}
+	}
+	if err := validateVarsOrder("random"); err == nil {
+		t.Error("validateVarsOrder(\"random\") expected an error")
+	}
+}
+
+func TestSortVarsFiles(t *testing.T) {
+	t.Run("check reports the variable files", func(t *testing.T) {
+		memFS := afero.NewMemMapFs()
+		_ = memFS.MkdirAll("/vars", 0755)
+		_ = afero.WriteFile(memFS, "/vars/variables.tf", []byte("variable \"region\" {}\n\nvariable \"ami\" {}\n"), 0644)
+		_ = afero.WriteFile(memFS, "/vars/terraform.tfvars", []byte(unsortedVars), 0644)
+		_ = afero.WriteFile(memFS, "/vars/extra.auto.tfvars.json", []byte(unsortedVarsJSON), 0644)
+		s := NewSorter(&Params{Check: true}, memFS)
+		err := s.run("/vars")
+		if !errors.Is(err, ErrCheckFailed) {
+			t.Fatalf("run() error = %v, want ErrCheckFailed", err)
+		}
+		for _, name := range []string{"terraform.tfvars", "extra.auto.tfvars.json"} {
+			if !strings.Contains(err.Error(), name) {
+				t.Errorf("expected %s in %v", name, err)
+			}
+		}
+	})
+
+	t.Run("group-by-type keeps the variable files", func(t *testing.T) {
+		memFS := afero.NewMemMapFs()
+		_ = memFS.MkdirAll("/vars", 0755)
+		_ = afero.WriteFile(memFS, "/vars/variables.tf", []byte("variable \"region\" {}\n\nvariable \"ami\" {}\n"), 0644)
+		_ = afero.WriteFile(memFS, "/vars/terraform.tfvars", []byte(unsortedVars), 0644)
+		_ = afero.WriteFile(memFS, "/vars/extra.auto.tfvars.json", []byte(unsortedVarsJSON), 0644)
+		s := NewSorter(&Params{GroupByType: true, OutputDir: "/out"}, memFS)
+		if err := s.run("/vars"); err != nil {
+			t.Fatalf("run() error = %v", err)
+		}
+		for _, name := range []string{"terraform.tfvars", "extra.auto.tfvars.json", "variables.tf"} {
+			if exists, _ := afero.Exists(memFS, "/out/"+name); !exists {
+				t.Errorf("expected %s in the output directory", name)
+			}
+		}
+	})
+
+	t.Run("declaration order skips excluded files", func(t *testing.T) {
+		memFS := afero.NewMemMapFs()
+		_ = memFS.MkdirAll("/vars", 0755)
+		_ = afero.WriteFile(memFS, "/vars/variables.tf", []byte("variable \"region\" {}\n\nvariable \"ami\" {}\n"), 0644)
+		_ = afero.WriteFile(memFS, "/vars/broken.tf", []byte("variable \"x\" {\n"), 0644)
+		_ = afero.WriteFile(memFS, "/vars/terraform.tfvars", []byte("ami = \"abc\"\nregion = \"us-east-1\"\n"), 0644)
+
+		s := NewSorter(&Params{Inline: true, VarsOrder: varsOrderDeclaration, Excludes: []string{"broken.tf"}}, memFS)
+		if err := s.run("/vars"); err != nil {
+			t.Fatalf("run() error = %v", err)
+		}
+		got, _ := afero.ReadFile(memFS, "/vars/terraform.tfvars")
+		if want := "region = \"us-east-1\"\nami    = \"abc\"\n"; string(got) != want {
+			t.Errorf("terraform.tfvars =\n%s\nwant:\n%s", got, want)
+		}
+	})
+
+	t.Run("recursive includes directories with only variable files", func(t *testing.T) {
+		memFS := afero.NewMemMapFs()
+		_ = memFS.MkdirAll("/root/env", 0755)
+		_ = afero.WriteFile(memFS, "/root/env/prod.tfvars", []byte("b = 1\na = 2\n"), 0644)
+
+		s := NewSorter(&Params{Check: true, Recursive: true}, memFS)
+		err := s.run("/root")
+		if !errors.Is(err, ErrCheckFailed) || !strings.Contains(err.Error(), "prod.tfvars") {
+			t.Errorf("run() error = %v, want ErrCheckFailed for prod.tfvars", err)
+		}
+	})
+}